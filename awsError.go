@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// clientTokenTagKey tag of client token, creates of ec2 api without ClientToken parameter.
+const clientTokenTagKey = "MigrateClientToken"
+
+// errorClass how an aws error is handled.
+type errorClass int
+
+const (
+	// errTerminal surfaced with resource context.
+	errTerminal errorClass = iota
+	// errThrottled retried with backoff, rate of service slowed down.
+	errThrottled
+	// errTransient retried with backoff.
+	errTransient
+	// errIdempotent change already made, treated as success.
+	errIdempotent
+)
+
+var (
+	throttleCodes = map[string]bool{
+		"RequestLimitExceeded":     true,
+		"Throttling":               true,
+		"ThrottlingException":      true,
+		"TooManyRequestsException": true,
+		"PriorRequestNotComplete":  true,
+		"EC2ThrottledException":    true,
+	}
+
+	transientCodes = map[string]bool{
+		"InternalError":                 true,
+		"InternalFailure":               true,
+		"InternalServiceErrorException": true,
+		"ServiceUnavailable":            true,
+		"ServiceUnavailableException":   true,
+		"Unavailable":                   true,
+		"RequestTimeout":                true,
+		"RequestTimeoutException":       true,
+		aws.ErrCodeRead:                 true,
+	}
+)
+
+// resourceError terminal error of action on resource.
+type resourceError struct {
+	Action   string
+	Resource string
+	Err      error
+}
+
+func (e *resourceError) Error() string {
+	return fmt.Sprintf("%s %s, %v", e.Action, e.Resource, e.Err)
+}
+
+func (e *resourceError) Unwrap() error {
+	return e.Err
+}
+
+// errorCode aws error code, wrapped error included.
+func errorCode(err error) string {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		return aerr.Code()
+	}
+	return ""
+}
+
+// errorSummary code, or error of non aws error e.g. timeout.
+func errorSummary(err error) string {
+	if code := errorCode(err); len(code) > 0 {
+		return code
+	}
+	return err.Error()
+}
+
+// classifyError idempotent codes are the "already exists" / "not found" codes of the call.
+func classifyError(err error, idempotent ...string) errorClass {
+	var cancelErr *aws.RequestCanceledError
+	if errors.As(err, &cancelErr) || errors.Is(err, context.DeadlineExceeded) {
+		// call timeout retried, cancelled by signal not.
+		if rootCtx.Err() != nil {
+			return errTerminal
+		}
+		return errTransient
+	}
+
+	code := errorCode(err)
+
+	switch {
+	case throttleCodes[code]:
+		return errThrottled
+	case transientCodes[code]:
+		return errTransient
+	case len(code) > 0 && containsString(idempotent, code):
+		return errIdempotent
+	}
+
+	var sendErr *aws.RequestSendError
+	if errors.As(err, &sendErr) {
+		return errTransient
+	}
+
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() >= 500 {
+		return errTransient
+	}
+
+	return errTerminal
+}
+
+// clientToken idempotency token of one create, made once and reused by retries of the create.
+func clientToken(id string) *string {
+	return aws.String(id + "-" + time.Now().Format("20060102150405.000"))
+}
+
+// tokenTagSpecifications token tag of create without client token, first tag specification or a new one.
+func tokenTagSpecifications(specs []ec2.TagSpecification, resourceType ec2.ResourceType, token *string) []ec2.TagSpecification {
+	tag := ec2.Tag{Key: aws.String(clientTokenTagKey), Value: token}
+	if len(specs) == 0 {
+		return []ec2.TagSpecification{{ResourceType: resourceType, Tags: []ec2.Tag{tag}}}
+	}
+	specs[0].Tags = append(specs[0].Tags, tag)
+	return specs
+}
+
+// lookupCreate create of ec2 api without client token, resource tagged with token. A timed out or not
+// answered create may be done, so retry looks up the token tag first, found id set without create.
+func lookupCreate(svc *ec2.Client, token *string, id *string, create func(ctx context.Context) error) func(ctx context.Context) error {
+	sent := false
+	return func(ctx context.Context) error {
+		if sent {
+			result, err := svc.DescribeTagsRequest(&ec2.DescribeTagsInput{
+				Filters: []ec2.Filter{
+					{Name: aws.String("key"), Values: []string{clientTokenTagKey}},
+					{Name: aws.String("value"), Values: []string{aws.StringValue(token)}},
+				},
+			}).Send(ctx)
+			if err != nil {
+				return err
+			}
+			if len(result.Tags) > 0 {
+				*id = aws.StringValue(result.Tags[0].ResourceId)
+				log.Printf("Found %s Created By Previous Attempt.", *id)
+				return nil
+			}
+		}
+
+		err := create(ctx)
+		if classifyError(err) == errTransient {
+			sent = true
+		}
+		return err
+	}
+}
+
+// awsCall rate limited call, throttling and transient errors retried, idempotent codes treated as
+// success, dry run response returned as is, others wrapped with action and resource.
+func awsCall(service, action, resource string, idempotent []string, call func(ctx context.Context) error) error {
+	err := limitedCall(service, call)
+	if err == nil {
+		journalRecord("%s %s", action, resource)
+		return nil
+	}
+	if errorCode(err) == dryRunOperationCode {
+		return err
+	}
+
+	if classifyError(err, idempotent...) == errIdempotent {
+		log.Printf("%s %s, %s, Already Done.", action, resource, errorCode(err))
+		return nil
+	}
+
+	return &resourceError{
+		Action:   action,
+		Resource: resource,
+		Err:      err,
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err        error
+		idempotent []string
+		want       errorClass
+	}{
+		{awserr.New("RequestLimitExceeded", "", nil), nil, errThrottled},
+		{awserr.New("InternalError", "", nil), nil, errTransient},
+		{awserr.New("InvalidGroup.Duplicate", "", nil), []string{"InvalidGroup.Duplicate"}, errIdempotent},
+		{awserr.New("InvalidGroup.Duplicate", "", nil), nil, errTerminal},
+		{awserr.NewRequestFailure(awserr.New("Unknown", "", nil), http.StatusBadGateway, ""), nil, errTransient},
+		{awserr.NewRequestFailure(awserr.New("UnauthorizedOperation", "", nil), http.StatusForbidden, ""), nil, errTerminal},
+		{fmt.Errorf("create, %w", awserr.New("Throttling", "", nil)), nil, errThrottled},
+		{context.DeadlineExceeded, nil, errTransient},
+	}
+
+	for _, tt := range tests {
+		if got := classifyError(tt.err, tt.idempotent...); got != tt.want {
+			t.Errorf("classifyError(%v, %v) = %v, want %v", tt.err, tt.idempotent, got, tt.want)
+		}
+	}
+}
+
+func TestAWSCallRetry(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "text/xml")
+
+		if hits == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `<Response><Errors><Error><Code>RequestLimitExceeded</Code><Message>Request limit exceeded.</Message></Error></Errors><RequestID>1</RequestID></Response>`)
+			return
+		}
+		fmt.Fprint(w, `<DescribeVpcsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>2</requestId>
+<vpcSet><item><vpcId>vpc-1</vpcId></item></vpcSet></DescribeVpcsResponse>`)
+	}))
+	defer server.Close()
+
+	svc := ec2.New(newTestAWSConfig(server.URL))
+
+	var result *ec2.DescribeVpcsResponse
+	err := awsCall(serviceEC2, "Describe VPC", "vpc-1", nil, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeVpcsRequest(&ec2.DescribeVpcsInput{}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits != 2 {
+		t.Errorf("requests = %d, want 2", hits)
+	}
+	if len(result.Vpcs) != 1 {
+		t.Errorf("vpcs = %d, want 1", len(result.Vpcs))
+	}
+}
+
+func TestLookupCreate(t *testing.T) {
+	actions := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		actions = append(actions, r.Form.Get("Action"))
+		w.Header().Set("Content-Type", "text/xml")
+
+		switch r.Form.Get("Action") {
+		case "CreateVpc":
+			if r.Form.Get("TagSpecification.1.Tag.1.Value") != "vpc-1-token" {
+				t.Errorf("token tag = %v, want vpc-1-token", r.Form)
+			}
+			// created, but answered with server error.
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<Response><Errors><Error><Code>InternalError</Code><Message>An internal error has occurred.</Message></Error></Errors><RequestID>1</RequestID></Response>`)
+		case "DescribeTags":
+			fmt.Fprint(w, `<DescribeTagsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>2</requestId>
+<tagSet><item><resourceId>vpc-9</resourceId><resourceType>vpc</resourceType><key>MigrateClientToken</key><value>vpc-1-token</value></item></tagSet></DescribeTagsResponse>`)
+		}
+	}))
+	defer server.Close()
+
+	svc := ec2.New(newTestAWSConfig(server.URL))
+	token := aws.String("vpc-1-token")
+
+	var vpcID string
+	err := awsCall(serviceEC2, "Create VPC", "10.0.0.0/16", nil, lookupCreate(svc, token, &vpcID, func(ctx context.Context) error {
+		result, err := svc.CreateVpcRequest(&ec2.CreateVpcInput{
+			CidrBlock:         aws.String("10.0.0.0/16"),
+			TagSpecifications: tokenTagSpecifications(nil, ec2.ResourceTypeVpc, token),
+		}).Send(ctx)
+		if err == nil {
+			vpcID = aws.StringValue(result.Vpc.VpcId)
+		}
+		return err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"CreateVpc", "DescribeTags"}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+	if vpcID != "vpc-9" {
+		t.Errorf("vpc id = %s, want vpc-9", vpcID)
+	}
+}
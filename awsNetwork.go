@@ -0,0 +1,764 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+const (
+	// not in the sdk enum yet.
+	resourceTypeVPCEndpoint ec2.ResourceType = "vpc-endpoint"
+
+	// default deny entry of network acl, can not be changed.
+	naclDefaultRuleNumber = 32767
+
+	errCodeRouteExists       = "RouteAlreadyExists"
+	errCodeAlreadyAssociated = "Resource.AlreadyAssociated"
+	errCodeNACLEntryExists   = "NetworkAclEntryAlreadyExists"
+	errCodeNACLEntryNotFound = "InvalidNetworkAclEntry.NotFound"
+)
+
+// NetworkSync DHCP options, internet gateways, route tables, network ACLs and VPC endpoints of
+// Source VPCID created in Destination VPCID, ids of earlier migrate steps mapped.
+type NetworkSync struct {
+	src        *awsAuth
+	dst        *awsAuth
+	tagsConfig []Tag
+	peeringMap map[string]string
+
+	// resource type: source id: destination id, of migrate steps.
+	mappings map[string]map[string]string
+	// mappings of resource types not migrated, subnet by cidr, security group by name.
+	derived map[string]map[string]string
+}
+
+func newNetworkSync(awsAccount *AWSAccount, mappings map[string]map[string]string) *NetworkSync {
+	return &NetworkSync{
+		src:        &awsAccount.Source,
+		dst:        &awsAccount.Destination,
+		tagsConfig: awsAccount.Tags,
+		peeringMap: awsAccount.SecurityGroup.References.PeeringMap,
+		mappings:   mappings,
+		derived:    make(map[string]map[string]string),
+	}
+}
+
+func (netSync *NetworkSync) tagSpecifications(tags []ec2.Tag, resourceType ec2.ResourceType) []ec2.TagSpecification {
+	return snapshotTagSpecifications(mergeTags(tags, &netSync.tagsConfig), resourceType)
+}
+
+// mapID destination id of migrated resource, or derived if the resource type not migrated.
+func (netSync *NetworkSync) mapID(resource string, srcID string) (string, bool) {
+	if m, ok := netSync.mappings[resource]; ok {
+		dstID, ok := m[srcID]
+		return dstID, ok
+	}
+
+	if _, ok := netSync.derived[resource]; !ok {
+		netSync.derived[resource] = netSync.deriveMapping(resource)
+	}
+	dstID, ok := netSync.derived[resource][srcID]
+	return dstID, ok
+}
+
+func (netSync *NetworkSync) deriveMapping(resource string) map[string]string {
+	mapping := make(map[string]string)
+	if isDryRunID(aws.String(netSync.dst.VIPCID)) {
+		return mapping
+	}
+
+	switch resource {
+	case exportResourceSubnet:
+		dstCIDRMap := make(map[string]string)
+		for _, subnet := range getSubnetsInfo(netSync.dst) {
+			if aws.StringValue(subnet.VpcId) == netSync.dst.VIPCID {
+				dstCIDRMap[aws.StringValue(subnet.CidrBlock)] = aws.StringValue(subnet.SubnetId)
+			}
+		}
+		for _, subnet := range getSubnetsInfo(netSync.src) {
+			if dstID, ok := dstCIDRMap[aws.StringValue(subnet.CidrBlock)]; ok && aws.StringValue(subnet.VpcId) == netSync.src.VIPCID {
+				mapping[aws.StringValue(subnet.SubnetId)] = dstID
+			}
+		}
+	case exportResourceSG:
+		dstNameMap := make(map[string]string)
+		for _, sg := range GetSGListByVPC(netSync.dst, netSync.dst.VIPCID) {
+			dstNameMap[aws.StringValue(sg.GroupName)] = aws.StringValue(sg.GroupId)
+		}
+		for _, sg := range GetSGListByVPC(netSync.src, netSync.src.VIPCID) {
+			if dstID, ok := dstNameMap[aws.StringValue(sg.GroupName)]; ok {
+				mapping[aws.StringValue(sg.GroupId)] = dstID
+			}
+		}
+	}
+
+	if len(mapping) > 0 {
+		log.Printf("%s Not Migrated, Mapped %d By Existing Destination Resources.", resource, len(mapping))
+	}
+	return mapping
+}
+
+// sendEC2 mutating call, false if not sent in dry run, recorded without call if ids has placeholder,
+// otherwise checked by DryRun parameter. Exit on error.
+func sendEC2(action string, resource string, ids []string, idempotent []string, call func(ctx context.Context) error) bool {
+	for _, id := range ids {
+		if isDryRunID(aws.String(id)) {
+			dryRunRecord("%s %s", action, resource)
+			return false
+		}
+	}
+
+	err := awsCall(serviceEC2, action, resource, idempotent, call)
+	if err != nil {
+		if dryRunOK(err, "%s %s", action, resource) {
+			return false
+		}
+		log.Fatalln(err)
+	}
+	return true
+}
+
+// describeByVPC describe resources of account VPCID, nothing if VPC not created in dry run.
+func describeByVPC(account *awsAuth, filterName string, describe func(ctx context.Context, filters []ec2.Filter) error) {
+	if isDryRunID(aws.String(account.VIPCID)) {
+		return
+	}
+
+	filters := []ec2.Filter{
+		{
+			Name:   aws.String(filterName),
+			Values: []string{account.VIPCID},
+		},
+	}
+	err := limitedCall(serviceEC2, func(ctx context.Context) error {
+		return describe(ctx, filters)
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// SyncDHCPOptions DHCP options of source VPC associated with destination VPC, default options skipped.
+func (netSync *NetworkSync) SyncDHCPOptions() map[string]string {
+	svc := newSVC(netSync.dst)
+	dhcpMap := make(map[string]string)
+
+	srcID := aws.StringValue(getVPCInfo(netSync.src, netSync.src.VIPCID).DhcpOptionsId)
+	if len(srcID) == 0 || srcID == "default" {
+		log.Print("Source VPC Uses Default DHCP Options, Skip.")
+		return dhcpMap
+	}
+
+	srcSVC := newSVC(netSync.src)
+	var options *ec2.DescribeDhcpOptionsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		options, err = srcSVC.DescribeDhcpOptionsRequest(&ec2.DescribeDhcpOptionsInput{
+			DhcpOptionsIds: []string{srcID},
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// destination options by configuration, reuse on rerun.
+	dstOptions := make(map[string]string)
+	for _, opts := range getDHCPOptions(netSync.dst) {
+		dstOptions[dhcpConfigurationKey(opts.DhcpConfigurations)] = aws.StringValue(opts.DhcpOptionsId)
+	}
+
+	for _, opts := range options.DhcpOptions {
+		if dstID, ok := dstOptions[dhcpConfigurationKey(opts.DhcpConfigurations)]; ok {
+			log.Printf("DHCP Options %s Same As %s, Reuse It.", dstID, srcID)
+			netSync.associateDHCPOptions(svc, dstID)
+			dhcpMap[srcID] = dstID
+			continue
+		}
+
+		configs := []ec2.NewDhcpConfiguration{}
+		for _, c := range opts.DhcpConfigurations {
+			values := []string{}
+			for _, v := range c.Values {
+				values = append(values, aws.StringValue(v.Value))
+			}
+			configs = append(configs, ec2.NewDhcpConfiguration{Key: c.Key, Values: values})
+		}
+
+		dstID := aws.StringValue(dryRunID(srcID))
+		token := clientToken(srcID)
+		var createdID string
+		if sendEC2("Create DHCP Options", srcID, nil, nil, lookupCreate(svc, token, &createdID, func(ctx context.Context) error {
+			result, err := svc.CreateDhcpOptionsRequest(&ec2.CreateDhcpOptionsInput{
+				DryRun:             aws.Bool(dryRun),
+				DhcpConfigurations: configs,
+				TagSpecifications:  tokenTagSpecifications(netSync.tagSpecifications(opts.Tags, ec2.ResourceTypeDhcpOptions), ec2.ResourceTypeDhcpOptions, token),
+			}).Send(ctx)
+			if err == nil {
+				createdID = aws.StringValue(result.DhcpOptions.DhcpOptionsId)
+			}
+			return err
+		})) {
+			dstID = createdID
+		}
+
+		netSync.associateDHCPOptions(svc, dstID)
+		dhcpMap[srcID] = dstID
+	}
+
+	log.Print("DHCP Options Migrate Done.")
+	return dhcpMap
+}
+
+func (netSync *NetworkSync) associateDHCPOptions(svc *ec2.Client, dstID string) {
+	sendEC2("Associate DHCP Options", dstID+" With VPC "+netSync.dst.VIPCID, []string{dstID, netSync.dst.VIPCID}, nil, func(ctx context.Context) error {
+		_, err := svc.AssociateDhcpOptionsRequest(&ec2.AssociateDhcpOptionsInput{
+			DryRun:        aws.Bool(dryRun),
+			DhcpOptionsId: aws.String(dstID),
+			VpcId:         aws.String(netSync.dst.VIPCID),
+		}).Send(ctx)
+		return err
+	})
+}
+
+func getDHCPOptions(account *awsAuth) []ec2.DhcpOptions {
+	svc := newSVC(account)
+
+	var result *ec2.DescribeDhcpOptionsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeDhcpOptionsRequest(&ec2.DescribeDhcpOptionsInput{}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return result.DhcpOptions
+}
+
+// dhcpConfigurationKey keys and values sorted, same key of same options.
+func dhcpConfigurationKey(configs []ec2.DhcpConfiguration) string {
+	keys := []string{}
+	for _, c := range configs {
+		values := []string{}
+		for _, v := range c.Values {
+			values = append(values, aws.StringValue(v.Value))
+		}
+		sort.Strings(values)
+		keys = append(keys, aws.StringValue(c.Key)+"="+strings.Join(values, ","))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ";")
+}
+
+func getInternetGateways(account *awsAuth) []ec2.InternetGateway {
+	svc := newSVC(account)
+
+	var igws []ec2.InternetGateway
+	describeByVPC(account, "attachment.vpc-id", func(ctx context.Context, filters []ec2.Filter) error {
+		result, err := svc.DescribeInternetGatewaysRequest(&ec2.DescribeInternetGatewaysInput{Filters: filters}).Send(ctx)
+		if err == nil {
+			igws = result.InternetGateways
+		}
+		return err
+	})
+	return igws
+}
+
+// SyncInternetGateways internet gateway attached to destination VPC, reuse the attached one.
+func (netSync *NetworkSync) SyncInternetGateways() map[string]string {
+	svc := newSVC(netSync.dst)
+	igwMap := make(map[string]string)
+
+	dstIGWs := getInternetGateways(netSync.dst)
+
+	for _, igw := range getInternetGateways(netSync.src) {
+		srcID := aws.StringValue(igw.InternetGatewayId)
+
+		// one internet gateway per VPC.
+		if len(dstIGWs) > 0 {
+			dstID := aws.StringValue(dstIGWs[0].InternetGatewayId)
+			log.Printf("Internet Gateway %s Already Attached To VPC %s, Reuse It.", dstID, netSync.dst.VIPCID)
+			igwMap[srcID] = dstID
+			continue
+		}
+
+		dstID := aws.StringValue(dryRunID(srcID))
+		token := clientToken(srcID)
+		var createdID string
+		if sendEC2("Create Internet Gateway", srcID, nil, nil, lookupCreate(svc, token, &createdID, func(ctx context.Context) error {
+			result, err := svc.CreateInternetGatewayRequest(&ec2.CreateInternetGatewayInput{
+				DryRun:            aws.Bool(dryRun),
+				TagSpecifications: tokenTagSpecifications(netSync.tagSpecifications(igw.Tags, ec2.ResourceTypeInternetGateway), ec2.ResourceTypeInternetGateway, token),
+			}).Send(ctx)
+			if err == nil {
+				createdID = aws.StringValue(result.InternetGateway.InternetGatewayId)
+			}
+			return err
+		})) {
+			dstID = createdID
+		}
+
+		sendEC2("Attach Internet Gateway", dstID+" To VPC "+netSync.dst.VIPCID, []string{dstID, netSync.dst.VIPCID}, []string{errCodeAlreadyAssociated}, func(ctx context.Context) error {
+			_, err := svc.AttachInternetGatewayRequest(&ec2.AttachInternetGatewayInput{
+				DryRun:            aws.Bool(dryRun),
+				InternetGatewayId: aws.String(dstID),
+				VpcId:             aws.String(netSync.dst.VIPCID),
+			}).Send(ctx)
+			return err
+		})
+
+		igwMap[srcID] = dstID
+	}
+
+	log.Print("Internet Gateway Migrate Done.")
+	return igwMap
+}
+
+func isMainRouteTable(rt ec2.RouteTable) bool {
+	for _, assoc := range rt.Associations {
+		if aws.BoolValue(assoc.Main) {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncRouteTables main route table mapped to destination main, others reused by Name tag or created,
+// routes of migrated targets created, subnet and gateway associations of mapped ids.
+func (netSync *NetworkSync) SyncRouteTables() map[string]string {
+	svc := newSVC(netSync.dst)
+	rtMap := make(map[string]string)
+
+	dstNames := make(map[string]string)
+	var dstMain string
+	if !isDryRunID(aws.String(netSync.dst.VIPCID)) {
+		for _, rt := range getRouteTables(netSync.dst) {
+			if isMainRouteTable(rt) {
+				dstMain = aws.StringValue(rt.RouteTableId)
+			}
+			if name := tagName(rt.Tags); len(name) > 0 {
+				dstNames[name] = aws.StringValue(rt.RouteTableId)
+			}
+		}
+	}
+
+	for _, rt := range getRouteTables(netSync.src) {
+		srcID := aws.StringValue(rt.RouteTableId)
+		dstID := aws.StringValue(dryRunID(srcID))
+
+		switch {
+		case isMainRouteTable(rt):
+			if len(dstMain) > 0 {
+				dstID = dstMain
+			}
+		case len(dstNames[tagName(rt.Tags)]) > 0 && len(tagName(rt.Tags)) > 0:
+			dstID = dstNames[tagName(rt.Tags)]
+			log.Printf("Route Table %s Already Exists, Reuse %s.", tagName(rt.Tags), dstID)
+		default:
+			token := clientToken(srcID)
+			var createdID string
+			if sendEC2("Create Route Table", srcID+" In VPC "+netSync.dst.VIPCID, []string{netSync.dst.VIPCID}, nil, lookupCreate(svc, token, &createdID, func(ctx context.Context) error {
+				result, err := svc.CreateRouteTableRequest(&ec2.CreateRouteTableInput{
+					DryRun:            aws.Bool(dryRun),
+					VpcId:             aws.String(netSync.dst.VIPCID),
+					TagSpecifications: tokenTagSpecifications(netSync.tagSpecifications(rt.Tags, ec2.ResourceTypeRouteTable), ec2.ResourceTypeRouteTable, token),
+				}).Send(ctx)
+				if err == nil {
+					createdID = aws.StringValue(result.RouteTable.RouteTableId)
+				}
+				return err
+			})) {
+				dstID = createdID
+			}
+		}
+
+		netSync.createRoutes(svc, dstID, rt)
+		netSync.associateRouteTable(svc, dstID, rt)
+
+		rtMap[srcID] = dstID
+	}
+
+	log.Print("Route Table Migrate Done.")
+	return rtMap
+}
+
+// routeTarget input with destination target of route, reason if target not migrated.
+func (netSync *NetworkSync) routeTarget(route ec2.Route, input *ec2.CreateRouteInput) string {
+	gatewayID := aws.StringValue(route.GatewayId)
+
+	switch {
+	case strings.HasPrefix(gatewayID, "igw-"):
+		dstID, ok := netSync.mapID(migrateResourceIGW, gatewayID)
+		if !ok {
+			return "internet gateway not migrated"
+		}
+		input.GatewayId = aws.String(dstID)
+	case route.VpcPeeringConnectionId != nil:
+		dstID, ok := netSync.peeringMap[aws.StringValue(route.VpcPeeringConnectionId)]
+		if !ok {
+			return "peering connection not in SecurityGroup References PeeringMap"
+		}
+		input.VpcPeeringConnectionId = aws.String(dstID)
+	case strings.HasPrefix(gatewayID, "vpce-"):
+		return "created by gateway endpoint"
+	case len(gatewayID) > 0:
+		return "virtual private gateway not migrated"
+	case route.NatGatewayId != nil:
+		return "NAT gateway not migrated"
+	case route.TransitGatewayId != nil:
+		return "transit gateway attachment not migrated"
+	case route.EgressOnlyInternetGatewayId != nil:
+		return "egress only internet gateway not migrated"
+	default:
+		return "instance or network interface not migrated"
+	}
+
+	return ""
+}
+
+func (netSync *NetworkSync) createRoutes(svc *ec2.Client, dstID string, rt ec2.RouteTable) {
+	for _, route := range rt.Routes {
+		if aws.StringValue(route.GatewayId) == "local" || route.Origin == ec2.RouteOriginEnableVgwRoutePropagation {
+			continue
+		}
+
+		destination := aws.StringValue(route.DestinationCidrBlock) + aws.StringValue(route.DestinationIpv6CidrBlock) +
+			aws.StringValue(route.DestinationPrefixListId)
+
+		input := &ec2.CreateRouteInput{
+			DryRun:                   aws.Bool(dryRun),
+			RouteTableId:             aws.String(dstID),
+			DestinationCidrBlock:     route.DestinationCidrBlock,
+			DestinationIpv6CidrBlock: route.DestinationIpv6CidrBlock,
+		}
+		reason := netSync.routeTarget(route, input)
+		if len(reason) == 0 && route.DestinationPrefixListId != nil {
+			reason = "prefix list destination not migrated"
+		}
+		if len(reason) > 0 {
+			log.Printf("Skip Route %s Of %s, %s.", destination, aws.StringValue(rt.RouteTableId), reason)
+			continue
+		}
+
+		ids := []string{dstID, aws.StringValue(input.GatewayId)}
+		sendEC2("Create Route", destination+" In "+dstID, ids, []string{errCodeRouteExists}, func(ctx context.Context) error {
+			_, err := svc.CreateRouteRequest(input).Send(ctx)
+			return err
+		})
+	}
+}
+
+func (netSync *NetworkSync) associateRouteTable(svc *ec2.Client, dstID string, rt ec2.RouteTable) {
+	for _, assoc := range rt.Associations {
+		input := &ec2.AssociateRouteTableInput{
+			DryRun:       aws.Bool(dryRun),
+			RouteTableId: aws.String(dstID),
+		}
+
+		var target string
+		switch {
+		case aws.BoolValue(assoc.Main):
+			continue
+		case assoc.SubnetId != nil:
+			subnetID, ok := netSync.mapID(exportResourceSubnet, aws.StringValue(assoc.SubnetId))
+			if !ok {
+				log.Printf("Skip Route Table %s Association, Subnet %s Not Migrated.", aws.StringValue(rt.RouteTableId), aws.StringValue(assoc.SubnetId))
+				continue
+			}
+			input.SubnetId, target = aws.String(subnetID), subnetID
+		case assoc.GatewayId != nil:
+			gatewayID, ok := netSync.mapID(migrateResourceIGW, aws.StringValue(assoc.GatewayId))
+			if !ok {
+				log.Printf("Skip Route Table %s Association, Gateway %s Not Migrated.", aws.StringValue(rt.RouteTableId), aws.StringValue(assoc.GatewayId))
+				continue
+			}
+			input.GatewayId, target = aws.String(gatewayID), gatewayID
+		default:
+			continue
+		}
+
+		sendEC2("Associate Route Table", dstID+" With "+target, []string{dstID, target}, []string{errCodeAlreadyAssociated}, func(ctx context.Context) error {
+			_, err := svc.AssociateRouteTableRequest(input).Send(ctx)
+			return err
+		})
+	}
+}
+
+func getNetworkACLs(account *awsAuth) []ec2.NetworkAcl {
+	svc := newSVC(account)
+
+	var acls []ec2.NetworkAcl
+	describeByVPC(account, "vpc-id", func(ctx context.Context, filters []ec2.Filter) error {
+		result, err := svc.DescribeNetworkAclsRequest(&ec2.DescribeNetworkAclsInput{Filters: filters}).Send(ctx)
+		if err == nil {
+			acls = result.NetworkAcls
+		}
+		return err
+	})
+	return acls
+}
+
+// SyncNetworkACLs default network ACL mapped to destination default, others reused by Name tag or created,
+// entries (CIDR translated) replaced, subnet associations of mapped subnets replaced.
+func (netSync *NetworkSync) SyncNetworkACLs(ct *CIDRTranslator) map[string]string {
+	svc := newSVC(netSync.dst)
+	aclMap := make(map[string]string)
+
+	dstACLs := getNetworkACLs(netSync.dst)
+	dstByID := make(map[string]ec2.NetworkAcl)
+	dstNames := make(map[string]string)
+	// destination subnet: current association
+	dstAssocs := make(map[string]ec2.NetworkAclAssociation)
+	var dstDefault string
+
+	for _, acl := range dstACLs {
+		dstByID[aws.StringValue(acl.NetworkAclId)] = acl
+		if aws.BoolValue(acl.IsDefault) {
+			dstDefault = aws.StringValue(acl.NetworkAclId)
+		}
+		if name := tagName(acl.Tags); len(name) > 0 {
+			dstNames[name] = aws.StringValue(acl.NetworkAclId)
+		}
+		for _, assoc := range acl.Associations {
+			dstAssocs[aws.StringValue(assoc.SubnetId)] = assoc
+		}
+	}
+
+	for _, acl := range getNetworkACLs(netSync.src) {
+		srcID := aws.StringValue(acl.NetworkAclId)
+		dstID := aws.StringValue(dryRunID(srcID))
+
+		switch {
+		case aws.BoolValue(acl.IsDefault):
+			if len(dstDefault) > 0 {
+				dstID = dstDefault
+			}
+		case len(dstNames[tagName(acl.Tags)]) > 0 && len(tagName(acl.Tags)) > 0:
+			dstID = dstNames[tagName(acl.Tags)]
+			log.Printf("Network ACL %s Already Exists, Reuse %s.", tagName(acl.Tags), dstID)
+		default:
+			token := clientToken(srcID)
+			var createdID string
+			if sendEC2("Create Network ACL", srcID+" In VPC "+netSync.dst.VIPCID, []string{netSync.dst.VIPCID}, nil, lookupCreate(svc, token, &createdID, func(ctx context.Context) error {
+				result, err := svc.CreateNetworkAclRequest(&ec2.CreateNetworkAclInput{
+					DryRun:            aws.Bool(dryRun),
+					VpcId:             aws.String(netSync.dst.VIPCID),
+					TagSpecifications: tokenTagSpecifications(netSync.tagSpecifications(acl.Tags, ec2.ResourceTypeNetworkAcl), ec2.ResourceTypeNetworkAcl, token),
+				}).Send(ctx)
+				if err == nil {
+					createdID = aws.StringValue(result.NetworkAcl.NetworkAclId)
+				}
+				return err
+			})) {
+				dstID = createdID
+			}
+		}
+
+		entries := ct.translateNetworkACLEntries("network acl "+srcID, acl.Entries)
+		netSync.syncNetworkACLEntries(svc, dstID, entries, dstByID[dstID].Entries)
+
+		for _, assoc := range acl.Associations {
+			subnetID, ok := netSync.mapID(exportResourceSubnet, aws.StringValue(assoc.SubnetId))
+			if !ok {
+				log.Printf("Skip Network ACL %s Association, Subnet %s Not Migrated.", srcID, aws.StringValue(assoc.SubnetId))
+				continue
+			}
+
+			current, ok := dstAssocs[subnetID]
+			if ok && aws.StringValue(current.NetworkAclId) == dstID {
+				continue
+			}
+			if !ok && !isDryRunID(aws.String(subnetID)) {
+				log.Printf("Not Found Network ACL Association Of Subnet %s, Skip.", subnetID)
+				continue
+			}
+
+			associationID := aws.StringValue(current.NetworkAclAssociationId)
+			sendEC2("Replace Network ACL Association", subnetID+" To "+dstID, []string{dstID, subnetID}, nil, func(ctx context.Context) error {
+				_, err := svc.ReplaceNetworkAclAssociationRequest(&ec2.ReplaceNetworkAclAssociationInput{
+					DryRun:        aws.Bool(dryRun),
+					AssociationId: aws.String(associationID),
+					NetworkAclId:  aws.String(dstID),
+				}).Send(ctx)
+				return err
+			})
+		}
+
+		aclMap[srcID] = dstID
+	}
+
+	log.Print("Network ACL Migrate Done.")
+	return aclMap
+}
+
+func naclEntryKey(entry ec2.NetworkAclEntry) string {
+	return fmt.Sprintf("%t/%d", aws.BoolValue(entry.Egress), aws.Int64Value(entry.RuleNumber))
+}
+
+// syncNetworkACLEntries entries created or replaced, destination entries not in source deleted.
+func (netSync *NetworkSync) syncNetworkACLEntries(svc *ec2.Client, dstID string, entries []ec2.NetworkAclEntry, dstEntries []ec2.NetworkAclEntry) {
+	srcKeys := make(map[string]bool)
+
+	for _, entry := range entries {
+		if aws.Int64Value(entry.RuleNumber) == naclDefaultRuleNumber {
+			continue
+		}
+		srcKeys[naclEntryKey(entry)] = true
+
+		entry := entry
+		resource := fmt.Sprintf("%s Rule %d(%s)", dstID, aws.Int64Value(entry.RuleNumber), naclEntryKey(entry))
+		if isDryRunID(aws.String(dstID)) {
+			dryRunRecord("Create Network ACL Entry %s", resource)
+			continue
+		}
+
+		err := awsCall(serviceEC2, "Create Network ACL Entry", resource, nil, func(ctx context.Context) error {
+			_, err := svc.CreateNetworkAclEntryRequest(&ec2.CreateNetworkAclEntryInput{
+				DryRun:        aws.Bool(dryRun),
+				NetworkAclId:  aws.String(dstID),
+				RuleNumber:    entry.RuleNumber,
+				Egress:        entry.Egress,
+				Protocol:      entry.Protocol,
+				RuleAction:    entry.RuleAction,
+				CidrBlock:     entry.CidrBlock,
+				Ipv6CidrBlock: entry.Ipv6CidrBlock,
+				IcmpTypeCode:  entry.IcmpTypeCode,
+				PortRange:     entry.PortRange,
+			}).Send(ctx)
+			return err
+		})
+		if errorCode(err) == errCodeNACLEntryExists {
+			err = awsCall(serviceEC2, "Replace Network ACL Entry", resource, nil, func(ctx context.Context) error {
+				_, err := svc.ReplaceNetworkAclEntryRequest(&ec2.ReplaceNetworkAclEntryInput{
+					DryRun:        aws.Bool(dryRun),
+					NetworkAclId:  aws.String(dstID),
+					RuleNumber:    entry.RuleNumber,
+					Egress:        entry.Egress,
+					Protocol:      entry.Protocol,
+					RuleAction:    entry.RuleAction,
+					CidrBlock:     entry.CidrBlock,
+					Ipv6CidrBlock: entry.Ipv6CidrBlock,
+					IcmpTypeCode:  entry.IcmpTypeCode,
+					PortRange:     entry.PortRange,
+				}).Send(ctx)
+				return err
+			})
+		}
+		if err != nil && !dryRunOK(err, "Create Network ACL Entry %s", resource) {
+			log.Fatalln(err)
+		}
+	}
+
+	for _, entry := range dstEntries {
+		if aws.Int64Value(entry.RuleNumber) == naclDefaultRuleNumber || srcKeys[naclEntryKey(entry)] {
+			continue
+		}
+
+		entry := entry
+		resource := fmt.Sprintf("%s Rule %d(%s)", dstID, aws.Int64Value(entry.RuleNumber), naclEntryKey(entry))
+		sendEC2("Delete Network ACL Entry", resource, nil, []string{errCodeNACLEntryNotFound}, func(ctx context.Context) error {
+			_, err := svc.DeleteNetworkAclEntryRequest(&ec2.DeleteNetworkAclEntryInput{
+				DryRun:       aws.Bool(dryRun),
+				NetworkAclId: aws.String(dstID),
+				RuleNumber:   entry.RuleNumber,
+				Egress:       entry.Egress,
+			}).Send(ctx)
+			return err
+		})
+	}
+}
+
+func getVPCEndpoints(account *awsAuth) []ec2.VpcEndpoint {
+	svc := newSVC(account)
+
+	var endpoints []ec2.VpcEndpoint
+	describeByVPC(account, "vpc-id", func(ctx context.Context, filters []ec2.Filter) error {
+		result, err := svc.DescribeVpcEndpointsRequest(&ec2.DescribeVpcEndpointsInput{Filters: filters}).Send(ctx)
+		if err == nil {
+			endpoints = result.VpcEndpoints
+		}
+		return err
+	})
+	return endpoints
+}
+
+// mapIDs destination ids of migrated resources, unmapped ids logged and skipped.
+func (netSync *NetworkSync) mapIDs(resource string, srcIDs []string, owner string) []string {
+	dstIDs := []string{}
+	for _, srcID := range srcIDs {
+		dstID, ok := netSync.mapID(resource, srcID)
+		if !ok {
+			log.Printf("%s %s Of %s Not Migrated, Skip.", resource, srcID, owner)
+			continue
+		}
+		dstIDs = append(dstIDs, dstID)
+	}
+	return dstIDs
+}
+
+// SyncVPCEndpoints endpoints of the same service name (region replaced), reuse endpoint of the service
+// in destination VPC, route tables, subnets and security groups mapped.
+func (netSync *NetworkSync) SyncVPCEndpoints() map[string]string {
+	svc := newSVC(netSync.dst)
+	endpointMap := make(map[string]string)
+
+	dstServices := make(map[string]string)
+	for _, ep := range getVPCEndpoints(netSync.dst) {
+		if ep.State != ec2.StateDeleted && ep.State != ec2.StateDeleting {
+			dstServices[aws.StringValue(ep.ServiceName)] = aws.StringValue(ep.VpcEndpointId)
+		}
+	}
+
+	for _, ep := range getVPCEndpoints(netSync.src) {
+		srcID := aws.StringValue(ep.VpcEndpointId)
+		serviceName := strings.Replace(aws.StringValue(ep.ServiceName), "."+netSync.src.Region+".", "."+netSync.dst.Region+".", 1)
+
+		if dstID, ok := dstServices[serviceName]; ok {
+			log.Printf("VPC Endpoint Of %s Already Exists, Reuse %s.", serviceName, dstID)
+			endpointMap[srcID] = dstID
+			continue
+		}
+
+		groupIDs := []string{}
+		for _, g := range ep.Groups {
+			groupIDs = append(groupIDs, aws.StringValue(g.GroupId))
+		}
+
+		input := &ec2.CreateVpcEndpointInput{
+			DryRun:            aws.Bool(dryRun),
+			ClientToken:       clientToken(srcID),
+			VpcId:             aws.String(netSync.dst.VIPCID),
+			ServiceName:       aws.String(serviceName),
+			VpcEndpointType:   ep.VpcEndpointType,
+			PolicyDocument:    ep.PolicyDocument,
+			RouteTableIds:     netSync.mapIDs(exportResourceRouteTable, ep.RouteTableIds, srcID),
+			SubnetIds:         netSync.mapIDs(exportResourceSubnet, ep.SubnetIds, srcID),
+			SecurityGroupIds:  netSync.mapIDs(exportResourceSG, groupIDs, srcID),
+			TagSpecifications: netSync.tagSpecifications(ep.Tags, resourceTypeVPCEndpoint),
+		}
+		if ep.VpcEndpointType == ec2.VpcEndpointTypeInterface {
+			input.PrivateDnsEnabled = ep.PrivateDnsEnabled
+		}
+
+		ids := append(append(append([]string{netSync.dst.VIPCID}, input.RouteTableIds...), input.SubnetIds...), input.SecurityGroupIds...)
+
+		dstID := aws.StringValue(dryRunID(srcID))
+		var result *ec2.CreateVpcEndpointResponse
+		if sendEC2("Create VPC Endpoint", serviceName+" In VPC "+netSync.dst.VIPCID, ids, nil, func(ctx context.Context) (err error) {
+			result, err = svc.CreateVpcEndpointRequest(input).Send(ctx)
+			return err
+		}) {
+			dstID = aws.StringValue(result.VpcEndpoint.VpcEndpointId)
+		}
+
+		endpointMap[srcID] = dstID
+	}
+
+	log.Print("VPC Endpoint Migrate Done.")
+	return endpointMap
+}
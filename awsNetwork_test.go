@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func TestDHCPConfigurationKey(t *testing.T) {
+	config := func(key string, values ...string) ec2.DhcpConfiguration {
+		c := ec2.DhcpConfiguration{Key: aws.String(key)}
+		for _, v := range values {
+			c.Values = append(c.Values, ec2.AttributeValue{Value: aws.String(v)})
+		}
+		return c
+	}
+
+	src := []ec2.DhcpConfiguration{
+		config("domain-name", "example.internal"),
+		config("domain-name-servers", "10.0.0.2", "10.0.0.3"),
+	}
+	dst := []ec2.DhcpConfiguration{
+		config("domain-name-servers", "10.0.0.3", "10.0.0.2"),
+		config("domain-name", "example.internal"),
+	}
+	if dhcpConfigurationKey(src) != dhcpConfigurationKey(dst) {
+		t.Errorf("key %q != %q, want same options", dhcpConfigurationKey(src), dhcpConfigurationKey(dst))
+	}
+
+	other := []ec2.DhcpConfiguration{
+		config("domain-name", "example.internal"),
+		config("domain-name-servers", "AmazonProvidedDNS"),
+	}
+	if dhcpConfigurationKey(src) == dhcpConfigurationKey(other) {
+		t.Errorf("key %q, want different options", dhcpConfigurationKey(other))
+	}
+}
@@ -2,13 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/aws/external"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 )
 
@@ -16,61 +15,30 @@ type route53Sync struct {
 	dstHostedZone    *route53.HostedZone
 	srcHostedZone    *route53.HostedZone
 	srcRecordListRes *route53.ListResourceRecordSetsResponse
+	config           *Route53Config
+	reports          []RecordChangeReport
 }
 
 func newRoute53SVC(account *awsAuth) *route53.Client {
-	cfg, err := external.LoadDefaultAWSConfig(
-		external.WithCredentialsProvider{
-			CredentialsProvider: aws.StaticCredentialsProvider{
-				Value: aws.Credentials{
-					AccessKeyID:     account.AccessKey,
-					SecretAccessKey: account.SecretKey,
-					Source:          "config file",
-					// SessionToken:    "",
-				},
-			},
-		},
-	)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to load config, %v", err)
-		os.Exit(1)
-	}
-
-	cfg.Region = account.Region
-
-	// Credentials retrieve will be called automatically internally to the SDK
-	// service clients created with the cfg value.
-	_, err = cfg.Credentials.Retrieve(context.Background())
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to get credentials, %v", err)
-		os.Exit(1)
-	}
-
-	return route53.New(cfg)
+	return route53.New(newAWSConfig(account))
 }
 
 func getDNSRecordList(awsAuth *awsAuth) (*route53.ListResourceRecordSetsResponse, *route53.HostedZone) {
 	svc := newRoute53SVC(awsAuth)
 
-	reqGet := svc.GetHostedZoneRequest(&route53.GetHostedZoneInput{
-		Id: &awsAuth.HostedZoneID,
+	var hostZone *route53.GetHostedZoneResponse
+	err := limitedCall(serviceRoute53, func(ctx context.Context) (err error) {
+		hostZone, err = svc.GetHostedZoneRequest(&route53.GetHostedZoneInput{
+			Id: &awsAuth.HostedZoneID,
+		}).Send(ctx)
+		return err
 	})
-	hostZone, err := reqGet.Send(context.Background())
 	if err != nil {
 		log.Println(err.Error())
 		return nil, nil
 	}
 
-	listParams := &route53.ListResourceRecordSetsInput{
-		HostedZoneId: &awsAuth.HostedZoneID, // Required
-		// MaxItems:              aws.String("100"),
-		// StartRecordIdentifier: aws.String("Sample update."),
-		// StartRecordName:       aws.String("com."),
-		// StartRecordType:       aws.String("CNAME"),
-	}
-
-	reqList := svc.ListResourceRecordSetsRequest(listParams)
-	respList, err := reqList.Send(context.Background())
+	respList, err := listRecordSets(svc, awsAuth.HostedZoneID)
 	if err != nil {
 		// Print the error, cast err to awserr.Error to get the Code and
 		// Message from an error.
@@ -82,22 +50,75 @@ func getDNSRecordList(awsAuth *awsAuth) (*route53.ListResourceRecordSetsResponse
 	return respList, hostZone.HostedZone
 }
 
+// listRecordSets all pages, records merged into the first response.
+func listRecordSets(svc *route53.Client, zoneID string) (*route53.ListResourceRecordSetsResponse, error) {
+	listParams := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID), // Required
+	}
+
+	var respList *route53.ListResourceRecordSetsResponse
+	for {
+		var page *route53.ListResourceRecordSetsResponse
+		err := limitedCall(serviceRoute53, func(ctx context.Context) (err error) {
+			page, err = svc.ListResourceRecordSetsRequest(listParams).Send(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if respList == nil {
+			respList = page
+		} else {
+			respList.ResourceRecordSets = append(respList.ResourceRecordSets, page.ResourceRecordSets...)
+		}
+
+		if !aws.BoolValue(page.IsTruncated) {
+			break
+		}
+		listParams.StartRecordName = page.NextRecordName
+		listParams.StartRecordType = page.NextRecordType
+		listParams.StartRecordIdentifier = page.NextRecordIdentifier
+	}
+	respList.IsTruncated = aws.Bool(false)
+
+	return respList, nil
+}
+
 func (r53sync *route53Sync) createHostedZone(awsAuth *awsAuth) *route53.HostedZone {
 	svc := newRoute53SVC(awsAuth)
 
-	reqCreate := svc.CreateHostedZoneRequest(&route53.CreateHostedZoneInput{
-		CallerReference: aws.String(time.Now().String()),
-		VPC: &route53.VPC{
-			VPCId:     &awsAuth.VIPCID,
-			VPCRegion: route53.VPCRegion(awsAuth.Region),
-		},
-		HostedZoneConfig: &route53.HostedZoneConfig{
-			Comment:     r53sync.srcHostedZone.Config.Comment,
-			PrivateZone: r53sync.srcHostedZone.Config.PrivateZone,
-		},
-		Name: r53sync.srcHostedZone.Name,
+	zoneName := r53sync.srcHostedZone.Name
+	if len(r53sync.config.ZoneName) > 0 {
+		zoneName = aws.String(normalizeDNSName(r53sync.config.ZoneName))
+	}
+
+	if dryRunSimulate("Create Hosted Zone %s With VPC %s", aws.StringValue(zoneName), awsAuth.VIPCID) {
+		return &route53.HostedZone{
+			Id:     dryRunID("hostedzone"),
+			Name:   zoneName,
+			Config: r53sync.srcHostedZone.Config,
+		}
+	}
+
+	callerReference := clientToken(trimHostedZoneID(aws.StringValue(r53sync.srcHostedZone.Id)))
+
+	var result *route53.CreateHostedZoneResponse
+	err := awsCall(serviceRoute53, "Create Hosted Zone", aws.StringValue(zoneName), nil, func(ctx context.Context) (err error) {
+		result, err = svc.CreateHostedZoneRequest(&route53.CreateHostedZoneInput{
+			CallerReference: callerReference,
+			VPC: &route53.VPC{
+				VPCId:     &awsAuth.VIPCID,
+				VPCRegion: route53.VPCRegion(awsAuth.Region),
+			},
+			HostedZoneConfig: &route53.HostedZoneConfig{
+				Comment:     r53sync.srcHostedZone.Config.Comment,
+				PrivateZone: r53sync.srcHostedZone.Config.PrivateZone,
+			},
+			Name: zoneName,
+		}).Send(ctx)
+		return err
 	})
-	result, err := reqCreate.Send(context.Background())
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -123,8 +144,8 @@ func (r53sync *route53Sync) createRecord(account *awsAuth, r53Action route53.Cha
 				MultiValueAnswer:        v.MultiValueAnswer,
 				Name:                    v.Name,
 				Type:                    v.Type,
-				ResourceRecords:         v.ResourceRecords,
-				TTL:                     v.TTL,
+				ResourceRecords:         r53sync.transformValues(v),
+				TTL:                     r53sync.transformTTL(v),
 				Weight:                  v.Weight,
 				SetIdentifier:           v.SetIdentifier,
 			},
@@ -133,6 +154,8 @@ func (r53sync *route53Sync) createRecord(account *awsAuth, r53Action route53.Cha
 		rrChangeList = append(rrChangeList, rrChange)
 	}
 
+	r53sync.writeReport()
+
 	log.Print("Create Resource Record.")
 
 	params := &route53.ChangeResourceRecordSetsInput{
@@ -143,8 +166,19 @@ func (r53sync *route53Sync) createRecord(account *awsAuth, r53Action route53.Cha
 		HostedZoneId: r53sync.dstHostedZone.Id,
 	}
 
-	req := svc.ChangeResourceRecordSetsRequest(params)
-	_, err := req.Send(context.Background())
+	if dryRunSimulate("Change %d Resource Record Sets In Hosted Zone %s", len(rrChangeList), aws.StringValue(r53sync.dstHostedZone.Id)) {
+		buff, err := json.MarshalIndent(params.ChangeBatch, "", "  ")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(string(buff))
+		return
+	}
+
+	err := awsCall(serviceRoute53, "Change Resource Record Sets", aws.StringValue(r53sync.dstHostedZone.Id), nil, func(ctx context.Context) error {
+		_, err := svc.ChangeResourceRecordSetsRequest(params).Send(ctx)
+		return err
+	})
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -173,18 +207,33 @@ func (r53sync *route53Sync) removeSrcDefaultRecord() {
 func Route53SyncGO(awsAccount *AWSAccount) {
 	var r53sync route53Sync
 
+	r53sync.config = &awsAccount.Route53
 	r53sync.srcRecordListRes, r53sync.srcHostedZone = getDNSRecordList(&awsAccount.Source)
+	if r53sync.srcHostedZone == nil {
+		log.Fatalf("Unable to get hosted zone %q", awsAccount.Source.HostedZoneID)
+	}
 
 	r53sync.removeSrcDefaultRecord()
+	r53sync.filterRecords()
 
 	if len(awsAccount.Destination.HostedZoneID) > 0 {
 		_, r53sync.dstHostedZone = getDNSRecordList(&awsAccount.Destination)
+		if r53sync.dstHostedZone == nil {
+			log.Fatalf("Unable to get hosted zone %q", awsAccount.Destination.HostedZoneID)
+		}
+		r53sync.rewriteRecords()
 		r53sync.createRecord(&awsAccount.Destination, route53.ChangeActionUpsert)
 	} else {
 		log.Println("Not Host Zone, Ceate It.")
 		r53sync.dstHostedZone = r53sync.createHostedZone(&awsAccount.Destination)
+		awsAccount.Destination.HostedZoneID = trimHostedZoneID(aws.StringValue(r53sync.dstHostedZone.Id))
+		r53sync.rewriteRecords()
 		r53sync.createRecord(&awsAccount.Destination, route53.ChangeActionCreate)
 	}
 
+	r53sync.syncZoneTags(&awsAccount.Source, &awsAccount.Destination, awsAccount.Tags)
+	r53sync.syncQueryLogging(&awsAccount.Source, &awsAccount.Destination)
+	r53sync.syncDNSSEC(&awsAccount.Source, &awsAccount.Destination)
+
 	log.Print("Done.")
 }
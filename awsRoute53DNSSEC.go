@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// the sdk version has not DNSSEC api, call route53 rest api by signed request.
+const (
+	route53APIVersion    = "2013-04-01"
+	route53SigningRegion = "us-east-1"
+
+	dnssecSigning = "SIGNING"
+	dnssecKSKName = "ksk1"
+
+	errCodeKSKAlreadyExists = "KeySigningKeyAlreadyExists"
+)
+
+// route53Endpoint global endpoint, replaced by test server.
+var route53Endpoint = "https://route53.amazonaws.com"
+
+// DNSSECKey key signing key of GetDNSSEC.
+type DNSSECKey struct {
+	Name   string `xml:"Name"`
+	KmsArn string `xml:"KmsArn"`
+	Status string `xml:"Status"`
+}
+
+// DNSSECStatus ...
+type DNSSECStatus struct {
+	ServeSignature string      `xml:"Status>ServeSignature"`
+	KeySigningKeys []DNSSECKey `xml:"KeySigningKeys>member"`
+}
+
+type createKSKInput struct {
+	XMLName                 xml.Name `xml:"https://route53.amazonaws.com/doc/2013-04-01/ CreateKeySigningKeyRequest"`
+	CallerReference         string   `xml:"CallerReference"`
+	HostedZoneID            string   `xml:"HostedZoneId"`
+	KeyManagementServiceArn string   `xml:"KeyManagementServiceArn"`
+	Name                    string   `xml:"Name"`
+	Status                  string   `xml:"Status"`
+}
+
+type route53ErrorResponse struct {
+	Code      string `xml:"Error>Code"`
+	Message   string `xml:"Error>Message"`
+	RequestID string `xml:"RequestId"`
+}
+
+// route53Request signed route53 rest request, error response as awserr.RequestFailure.
+func route53Request(ctx context.Context, account *awsAuth, method, path string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		buff, err := xml.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = append([]byte(xml.Header), buff...)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, route53Endpoint+"/"+route53APIVersion+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+
+	hash := sha256.Sum256(body)
+	err = v4.NewSigner(newCredentials(account)).SignHTTP(ctx, req, hex.EncodeToString(hash[:]), "route53", route53SigningRegion, time.Now())
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return &aws.RequestSendError{Err: err}
+	}
+	defer res.Body.Close()
+
+	buff, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		var errRes route53ErrorResponse
+		if err := xml.Unmarshal(buff, &errRes); err != nil || len(errRes.Code) == 0 {
+			errRes.Code = http.StatusText(res.StatusCode)
+			errRes.Message = string(buff)
+		}
+		return awserr.NewRequestFailure(awserr.New(errRes.Code, errRes.Message, nil), res.StatusCode, errRes.RequestID)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return xml.Unmarshal(buff, out)
+}
+
+func getDNSSEC(account *awsAuth, zoneID string) *DNSSECStatus {
+	status := &DNSSECStatus{}
+
+	err := awsCall(serviceRoute53, "Get DNSSEC", zoneID, nil, func(ctx context.Context) error {
+		return route53Request(ctx, account, http.MethodGet, "/hostedzone/"+zoneID+"/dnssec", nil, status)
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return status
+}
+
+// syncDNSSEC key signing keys of source (or one key if source not signing) created with
+// DNSSECKMSKeyARN, signing enabled. KMS key of source account can not be used by destination.
+func (r53sync *route53Sync) syncDNSSEC(src *awsAuth, dst *awsAuth) {
+	if aws.BoolValue(r53sync.srcHostedZone.Config.PrivateZone) || aws.BoolValue(r53sync.dstHostedZone.Config.PrivateZone) {
+		if len(r53sync.config.DNSSECKMSKeyARN) > 0 {
+			log.Print("DNSSEC Unsupported Private Hosted Zone, Ignore.")
+		}
+		return
+	}
+
+	srcStatus := getDNSSEC(src, trimHostedZoneID(aws.StringValue(r53sync.srcHostedZone.Id)))
+
+	if len(r53sync.config.DNSSECKMSKeyARN) == 0 {
+		if srcStatus.ServeSignature == dnssecSigning {
+			log.Print("Source Hosted Zone DNSSEC Signing, Set Route53 DNSSECKMSKeyARN To Replicate It, Ignore.")
+		}
+		return
+	}
+
+	keys := []DNSSECKey{}
+	for _, k := range srcStatus.KeySigningKeys {
+		switch k.Status {
+		case "ACTIVE", "INACTIVE":
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		keys = append(keys, DNSSECKey{Name: dnssecKSKName, Status: "ACTIVE"})
+	}
+
+	zoneID := trimHostedZoneID(aws.StringValue(r53sync.dstHostedZone.Id))
+
+	for _, k := range keys {
+		if dryRunSimulate("Create Key Signing Key %s(%s) In Hosted Zone %s", k.Name, k.Status, zoneID) {
+			continue
+		}
+
+		input := &createKSKInput{
+			CallerReference:         fmt.Sprintf("%s-%s-%d", zoneID, k.Name, time.Now().Unix()),
+			HostedZoneID:            zoneID,
+			KeyManagementServiceArn: r53sync.config.DNSSECKMSKeyARN,
+			Name:                    k.Name,
+			Status:                  k.Status,
+		}
+		err := awsCall(serviceRoute53, "Create Key Signing Key", k.Name, []string{errCodeKSKAlreadyExists}, func(ctx context.Context) error {
+			return route53Request(ctx, dst, http.MethodPost, "/keysigningkey", input, nil)
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if dryRunSimulate("Enable DNSSEC Signing Of Hosted Zone %s", zoneID) {
+		return
+	}
+
+	err := awsCall(serviceRoute53, "Enable Hosted Zone DNSSEC", zoneID, nil, func(ctx context.Context) error {
+		return route53Request(ctx, dst, http.MethodPost, "/hostedzone/"+zoneID+"/enable-dnssec", nil, nil)
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("DNSSEC Signing Enabled, Add DS Record Of Hosted Zone %s To Parent Zone.", zoneID)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoute53Request(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("request not signed, %q", r.Header.Get("Authorization"))
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /2013-04-01/hostedzone/Z1/dnssec":
+			w.Write([]byte(`<?xml version="1.0"?>
+<GetDNSSECResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <Status><ServeSignature>SIGNING</ServeSignature></Status>
+  <KeySigningKeys>
+    <member><Name>ksk1</Name><KmsArn>arn:aws:kms:us-east-1:111111111111:key/a</KmsArn><Status>ACTIVE</Status></member>
+  </KeySigningKeys>
+</GetDNSSECResponse>`))
+		case "POST /2013-04-01/keysigningkey":
+			body, _ := ioutil.ReadAll(r.Body)
+			if !strings.Contains(string(body), `<CreateKeySigningKeyRequest xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`) {
+				t.Errorf("unexpected body %s", body)
+			}
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`<?xml version="1.0"?>
+<ErrorResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <Error><Type>Sender</Type><Code>KeySigningKeyAlreadyExists</Code><Message>exists</Message></Error>
+  <RequestId>req-1</RequestId>
+</ErrorResponse>`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(endpoint string) { route53Endpoint = endpoint }(route53Endpoint)
+	route53Endpoint = server.URL
+
+	account := &awsAuth{AccessKey: "AKID", SecretKey: "SECRET", Region: "us-east-1"}
+
+	status := &DNSSECStatus{}
+	if err := route53Request(context.Background(), account, http.MethodGet, "/hostedzone/Z1/dnssec", nil, status); err != nil {
+		t.Fatal(err)
+	}
+	if status.ServeSignature != dnssecSigning || len(status.KeySigningKeys) != 1 || status.KeySigningKeys[0].Name != "ksk1" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	err := route53Request(context.Background(), account, http.MethodPost, "/keysigningkey", &createKSKInput{Name: "ksk1"}, nil)
+	if errorCode(err) != errCodeKSKAlreadyExists {
+		t.Errorf("error code = %q, want %q, %v", errorCode(err), errCodeKSKAlreadyExists, err)
+	}
+	if classifyError(err, errCodeKSKAlreadyExists) != errIdempotent {
+		t.Errorf("already exists not idempotent, %v", err)
+	}
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53resolver"
+)
+
+const resolverEndpointWaitInterval = 10 * time.Second
+
+// ResolverSync ...
+type ResolverSync struct {
+	config     *ResolverConfig
+	tagsConfig []Tag
+
+	// source id: destination id
+	subnetMap   map[string]string
+	subnetCIDR  map[string]string
+	sgMap       map[string]string
+	vpcMap      map[string]string
+	endpointMap map[string]string
+	ruleMap     map[string]string
+}
+
+func newResolverSVC(account *awsAuth) *route53resolver.Client {
+	return route53resolver.New(newAWSConfig(account))
+}
+
+// ResolverSyncGO ...
+func ResolverSyncGO(awsAccount *AWSAccount) {
+	var resolverSync ResolverSync
+
+	resolverSync.config = &awsAccount.Resolver
+	resolverSync.tagsConfig = awsAccount.Tags
+	resolverSync.endpointMap = make(map[string]string)
+	resolverSync.ruleMap = make(map[string]string)
+
+	resolverSync.buildSubnetMap(&awsAccount.Source, &awsAccount.Destination)
+	resolverSync.buildSGMap(&awsAccount.Source, &awsAccount.Destination)
+	resolverSync.buildVPCMap(&awsAccount.Source, &awsAccount.Destination)
+
+	resolverSync.createEndpoints(&awsAccount.Source, &awsAccount.Destination)
+	resolverSync.createRules(&awsAccount.Source, &awsAccount.Destination)
+	resolverSync.associateRules(&awsAccount.Source, &awsAccount.Destination)
+
+	log.Print("Resolver Migrate Done.")
+}
+
+// buildSubnetMap map source subnet to destination subnet by same cidr, config map first.
+func (resolverSync *ResolverSync) buildSubnetMap(src *awsAuth, dst *awsAuth) {
+	resolverSync.subnetMap = make(map[string]string)
+	resolverSync.subnetCIDR = make(map[string]string)
+
+	dstCIDRMap := make(map[string]string)
+	for _, subnet := range getSubnetsInfo(dst) {
+		if aws.StringValue(subnet.VpcId) != dst.VIPCID {
+			continue
+		}
+		dstCIDRMap[aws.StringValue(subnet.CidrBlock)] = aws.StringValue(subnet.SubnetId)
+		resolverSync.subnetCIDR[aws.StringValue(subnet.SubnetId)] = aws.StringValue(subnet.CidrBlock)
+	}
+
+	for _, subnet := range getSubnetsInfo(src) {
+		if aws.StringValue(subnet.VpcId) != src.VIPCID {
+			continue
+		}
+		if dstID, ok := dstCIDRMap[aws.StringValue(subnet.CidrBlock)]; ok {
+			resolverSync.subnetMap[aws.StringValue(subnet.SubnetId)] = dstID
+		}
+	}
+
+	for srcID, dstID := range resolverSync.config.SubnetMap {
+		resolverSync.subnetMap[srcID] = dstID
+	}
+}
+
+// buildSGMap map source security group to destination security group by same name, config map first.
+func (resolverSync *ResolverSync) buildSGMap(src *awsAuth, dst *awsAuth) {
+	resolverSync.sgMap = make(map[string]string)
+
+	dstNameMap := make(map[string]string)
+	for _, sg := range GetSGList(dst) {
+		if aws.StringValue(sg.VpcId) != dst.VIPCID {
+			continue
+		}
+		dstNameMap[aws.StringValue(sg.GroupName)] = aws.StringValue(sg.GroupId)
+	}
+
+	for _, sg := range GetSGListByVPC(src) {
+		if dstID, ok := dstNameMap[aws.StringValue(sg.GroupName)]; ok {
+			resolverSync.sgMap[aws.StringValue(sg.GroupId)] = dstID
+		}
+	}
+
+	for srcID, dstID := range resolverSync.config.SecurityGroupMap {
+		resolverSync.sgMap[srcID] = dstID
+	}
+}
+
+func (resolverSync *ResolverSync) buildVPCMap(src *awsAuth, dst *awsAuth) {
+	resolverSync.vpcMap = map[string]string{
+		src.VIPCID: dst.VIPCID,
+	}
+
+	for srcID, dstID := range resolverSync.config.VPCMap {
+		resolverSync.vpcMap[srcID] = dstID
+	}
+}
+
+func (resolverSync *ResolverSync) setTags() []route53resolver.Tag {
+	tags := []route53resolver.Tag{
+		{
+			Key:   aws.String("CreateAt"),
+			Value: aws.String(time.Now().String()),
+		},
+	}
+
+	for _, v := range resolverSync.tagsConfig {
+		tags = append(tags, route53resolver.Tag{
+			Key:   aws.String(v.Key),
+			Value: aws.String(v.Value),
+		})
+	}
+	return tags
+}
+
+func getResolverEndpoints(svc *route53resolver.Client, vpcID string) []route53resolver.ResolverEndpoint {
+	var endpoints []route53resolver.ResolverEndpoint
+
+	input := &route53resolver.ListResolverEndpointsInput{
+		Filters: []route53resolver.Filter{
+			{
+				Name:   aws.String("HostVPCId"),
+				Values: []string{vpcID},
+			},
+		},
+	}
+
+	for {
+		var result *route53resolver.ListResolverEndpointsResponse
+		err := limitedCall(serviceResolver, func(ctx context.Context) (err error) {
+			result, err = svc.ListResolverEndpointsRequest(input).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		endpoints = append(endpoints, result.ResolverEndpoints...)
+
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	return endpoints
+}
+
+func getResolverEndpointIPs(svc *route53resolver.Client, endpointID *string) []route53resolver.IpAddressResponse {
+	var ips []route53resolver.IpAddressResponse
+
+	input := &route53resolver.ListResolverEndpointIpAddressesInput{
+		ResolverEndpointId: endpointID,
+	}
+
+	for {
+		var result *route53resolver.ListResolverEndpointIpAddressesResponse
+		err := limitedCall(serviceResolver, func(ctx context.Context) (err error) {
+			result, err = svc.ListResolverEndpointIpAddressesRequest(input).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		ips = append(ips, result.IpAddresses...)
+
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	return ips
+}
+
+// cidrContains the ip can be keep, if destination subnet has same cidr.
+func cidrContains(cidr string, ip string) bool {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	return ipNet.Contains(net.ParseIP(ip))
+}
+
+// mapEndpointIPs destination subnet of endpoint ips, ip kept if the destination subnet has the same cidr.
+func (resolverSync *ResolverSync) mapEndpointIPs(endpointID string, ips []route53resolver.IpAddressResponse) ([]route53resolver.IpAddressRequest, error) {
+	ipRequests := []route53resolver.IpAddressRequest{}
+
+	for _, ip := range ips {
+		subnetID, ok := resolverSync.subnetMap[aws.StringValue(ip.SubnetId)]
+		if !ok {
+			return nil, fmt.Errorf("Not Found Destination Subnet In Map, Endpoint: %s, Subnet: %s",
+				endpointID, aws.StringValue(ip.SubnetId))
+		}
+
+		ipRequest := route53resolver.IpAddressRequest{
+			SubnetId: aws.String(subnetID),
+		}
+		if cidrContains(resolverSync.subnetCIDR[subnetID], aws.StringValue(ip.Ip)) {
+			ipRequest.Ip = ip.Ip
+		}
+
+		ipRequests = append(ipRequests, ipRequest)
+	}
+
+	return ipRequests, nil
+}
+
+// mapEndpointSGIDs destination security groups of endpoint.
+func (resolverSync *ResolverSync) mapEndpointSGIDs(endpointID string, sgIDs []string) ([]string, error) {
+	newIDs := []string{}
+	for _, sgID := range sgIDs {
+		newID, ok := resolverSync.sgMap[sgID]
+		if !ok {
+			return nil, fmt.Errorf("Not Found Destination Security Group In Map, Endpoint: %s, Security Group: %s",
+				endpointID, sgID)
+		}
+		newIDs = append(newIDs, newID)
+	}
+	return newIDs, nil
+}
+
+func (resolverSync *ResolverSync) createEndpoints(src *awsAuth, dst *awsAuth) {
+	srcSVC := newResolverSVC(src)
+	dstSVC := newResolverSVC(dst)
+
+	for _, endpoint := range getResolverEndpoints(srcSVC, src.VIPCID) {
+		ipRequests, err := resolverSync.mapEndpointIPs(aws.StringValue(endpoint.Id), getResolverEndpointIPs(srcSVC, endpoint.Id))
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		sgIDs, err := resolverSync.mapEndpointSGIDs(aws.StringValue(endpoint.Id), endpoint.SecurityGroupIds)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dryRunSimulate("Create Resolver Endpoint %s(%s), IPs: %d", aws.StringValue(endpoint.Name), endpoint.Direction, len(ipRequests)) {
+			resolverSync.endpointMap[aws.StringValue(endpoint.Id)] = aws.StringValue(dryRunID(aws.StringValue(endpoint.Id)))
+			continue
+		}
+
+		requestID := clientToken(aws.StringValue(endpoint.Id))
+
+		var result *route53resolver.CreateResolverEndpointResponse
+		err = awsCall(serviceResolver, "Create Resolver Endpoint", aws.StringValue(endpoint.Name), nil, func(ctx context.Context) (err error) {
+			result, err = dstSVC.CreateResolverEndpointRequest(&route53resolver.CreateResolverEndpointInput{
+				CreatorRequestId: requestID,
+				Direction:        endpoint.Direction,
+				IpAddresses:      ipRequests,
+				Name:             endpoint.Name,
+				SecurityGroupIds: sgIDs,
+				Tags:             resolverSync.setTags(),
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		resolverSync.endpointMap[aws.StringValue(endpoint.Id)] = aws.StringValue(result.ResolverEndpoint.Id)
+
+		log.Printf("Created Resolver Endpoint: %s(%s), %s -> %s", aws.StringValue(endpoint.Name), endpoint.Direction,
+			aws.StringValue(endpoint.Id), aws.StringValue(result.ResolverEndpoint.Id))
+	}
+
+	for _, id := range resolverSync.endpointMap {
+		if isDryRunID(aws.String(id)) {
+			continue
+		}
+		waitResolverEndpoint(dstSVC, id)
+	}
+}
+
+func waitResolverEndpoint(svc *route53resolver.Client, endpointID string) {
+	deadline := time.Now().Add(waitTimeout())
+
+	for {
+		var result *route53resolver.GetResolverEndpointResponse
+		err := limitedCall(serviceResolver, func(ctx context.Context) (err error) {
+			result, err = svc.GetResolverEndpointRequest(&route53resolver.GetResolverEndpointInput{
+				ResolverEndpointId: aws.String(endpointID),
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		switch result.ResolverEndpoint.Status {
+		case route53resolver.ResolverEndpointStatusOperational:
+			return
+		case route53resolver.ResolverEndpointStatusCreating:
+			if time.Now().After(deadline) {
+				log.Fatalf("Wait Resolver Endpoint: %s Timeout After %v", endpointID, waitTimeout())
+			}
+			log.Printf("Wait Resolver Endpoint: %s, %s", endpointID, result.ResolverEndpoint.Status)
+			if !sleepContext(resolverEndpointWaitInterval) {
+				exitIfCancelled()
+			}
+		default:
+			log.Fatalf("Resolver Endpoint: %s, %s, %s", endpointID, result.ResolverEndpoint.Status,
+				aws.StringValue(result.ResolverEndpoint.StatusMessage))
+		}
+	}
+}
+
+func getResolverRules(svc *route53resolver.Client) []route53resolver.ResolverRule {
+	var rules []route53resolver.ResolverRule
+
+	input := &route53resolver.ListResolverRulesInput{}
+
+	for {
+		var result *route53resolver.ListResolverRulesResponse
+		err := limitedCall(serviceResolver, func(ctx context.Context) (err error) {
+			result, err = svc.ListResolverRulesRequest(input).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		rules = append(rules, result.ResolverRules...)
+
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	return rules
+}
+
+// mapRuleEndpoint destination endpoint of rule, nil if rule has no endpoint,
+// false if the endpoint not in source VPC.
+func (resolverSync *ResolverSync) mapRuleEndpoint(rule route53resolver.ResolverRule) (*string, bool) {
+	if rule.ResolverEndpointId == nil {
+		return nil, true
+	}
+
+	newID, ok := resolverSync.endpointMap[aws.StringValue(rule.ResolverEndpointId)]
+	if !ok {
+		log.Printf("Resolver Rule: %s, Endpoint %s Not In Source VPC, Ignore.",
+			aws.StringValue(rule.Id), aws.StringValue(rule.ResolverEndpointId))
+		return nil, false
+	}
+	return aws.String(newID), true
+}
+
+func (resolverSync *ResolverSync) createRules(src *awsAuth, dst *awsAuth) {
+	srcSVC := newResolverSVC(src)
+	dstSVC := newResolverSVC(dst)
+
+	accountID := getAccountID(src)
+
+	for _, rule := range getResolverRules(srcSVC) {
+		// auto defined rule and shared rule, not owner by this account.
+		if aws.StringValue(rule.OwnerId) != accountID {
+			continue
+		}
+
+		endpointID, ok := resolverSync.mapRuleEndpoint(rule)
+		if !ok {
+			continue
+		}
+
+		if dryRunSimulate("Create Resolver Rule %s(%s)", aws.StringValue(rule.DomainName), rule.RuleType) {
+			resolverSync.ruleMap[aws.StringValue(rule.Id)] = aws.StringValue(dryRunID(aws.StringValue(rule.Id)))
+			continue
+		}
+
+		requestID := clientToken(aws.StringValue(rule.Id))
+
+		var result *route53resolver.CreateResolverRuleResponse
+		err := awsCall(serviceResolver, "Create Resolver Rule", aws.StringValue(rule.DomainName), nil, func(ctx context.Context) (err error) {
+			result, err = dstSVC.CreateResolverRuleRequest(&route53resolver.CreateResolverRuleInput{
+				CreatorRequestId:   requestID,
+				DomainName:         rule.DomainName,
+				Name:               rule.Name,
+				ResolverEndpointId: endpointID,
+				RuleType:           rule.RuleType,
+				TargetIps:          rule.TargetIps,
+				Tags:               resolverSync.setTags(),
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		resolverSync.ruleMap[aws.StringValue(rule.Id)] = aws.StringValue(result.ResolverRule.Id)
+
+		log.Printf("Created Resolver Rule: %s(%s), %s -> %s", aws.StringValue(rule.DomainName), rule.RuleType,
+			aws.StringValue(rule.Id), aws.StringValue(result.ResolverRule.Id))
+	}
+}
+
+// mapAssociation destination rule and VPC of association, false if the rule not migrated
+// or the VPC not in map.
+func (resolverSync *ResolverSync) mapAssociation(association route53resolver.ResolverRuleAssociation) (string, string, bool) {
+	ruleID, ok := resolverSync.ruleMap[aws.StringValue(association.ResolverRuleId)]
+	if !ok {
+		return "", "", false
+	}
+
+	vpcID, ok := resolverSync.vpcMap[aws.StringValue(association.VPCId)]
+	if !ok {
+		log.Printf("Resolver Rule Association: %s, VPC %s Not In Map, Ignore.",
+			aws.StringValue(association.Id), aws.StringValue(association.VPCId))
+		return "", "", false
+	}
+
+	return ruleID, vpcID, true
+}
+
+func (resolverSync *ResolverSync) associateRules(src *awsAuth, dst *awsAuth) {
+	srcSVC := newResolverSVC(src)
+	dstSVC := newResolverSVC(dst)
+
+	input := &route53resolver.ListResolverRuleAssociationsInput{}
+	associations := []route53resolver.ResolverRuleAssociation{}
+
+	for {
+		var result *route53resolver.ListResolverRuleAssociationsResponse
+		err := limitedCall(serviceResolver, func(ctx context.Context) (err error) {
+			result, err = srcSVC.ListResolverRuleAssociationsRequest(input).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		associations = append(associations, result.ResolverRuleAssociations...)
+
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	for _, association := range associations {
+		ruleID, vpcID, ok := resolverSync.mapAssociation(association)
+		if !ok {
+			continue
+		}
+
+		if dryRunSimulate("Associate Resolver Rule %s With VPC %s", ruleID, vpcID) {
+			continue
+		}
+
+		err := awsCall(serviceResolver, "Associate Resolver Rule", ruleID+" With VPC "+vpcID,
+			[]string{route53resolver.ErrCodeResourceExistsException}, func(ctx context.Context) error {
+				_, err := dstSVC.AssociateResolverRuleRequest(&route53resolver.AssociateResolverRuleInput{
+					Name:           association.Name,
+					ResolverRuleId: aws.String(ruleID),
+					VPCId:          aws.String(vpcID),
+				}).Send(ctx)
+				return err
+			})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		log.Printf("Associated Resolver Rule: %s, VPC: %s", ruleID, vpcID)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53resolver"
+)
+
+func TestMapEndpointIPs(t *testing.T) {
+	resolverSync := &ResolverSync{
+		subnetMap:  map[string]string{"subnet-a": "subnet-1", "subnet-b": "subnet-2"},
+		subnetCIDR: map[string]string{"subnet-1": "10.0.1.0/24", "subnet-2": "172.16.2.0/24"},
+	}
+
+	tests := []struct {
+		ips     []route53resolver.IpAddressResponse
+		want    []route53resolver.IpAddressRequest
+		wantErr bool
+	}{
+		// ip kept in the same cidr, assigned by aws if not.
+		{[]route53resolver.IpAddressResponse{
+			{SubnetId: aws.String("subnet-a"), Ip: aws.String("10.0.1.10")},
+			{SubnetId: aws.String("subnet-b"), Ip: aws.String("10.0.2.10")},
+		}, []route53resolver.IpAddressRequest{
+			{SubnetId: aws.String("subnet-1"), Ip: aws.String("10.0.1.10")},
+			{SubnetId: aws.String("subnet-2")},
+		}, false},
+		{[]route53resolver.IpAddressResponse{
+			{SubnetId: aws.String("subnet-c"), Ip: aws.String("10.0.3.10")},
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolverSync.mapEndpointIPs("rslvr-in-1", tt.ips)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mapEndpointIPs error = %v, want error %t", err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mapEndpointIPs = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestMapEndpointSGIDs(t *testing.T) {
+	resolverSync := &ResolverSync{sgMap: map[string]string{"sg-a": "sg-1", "sg-b": "sg-2"}}
+
+	tests := []struct {
+		sgIDs   []string
+		want    []string
+		wantErr bool
+	}{
+		{[]string{"sg-a", "sg-b"}, []string{"sg-1", "sg-2"}, false},
+		{[]string{}, []string{}, false},
+		{[]string{"sg-a", "sg-c"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolverSync.mapEndpointSGIDs("rslvr-in-1", tt.sgIDs)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mapEndpointSGIDs(%v) error = %v, want error %t", tt.sgIDs, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mapEndpointSGIDs(%v) = %v, want %v", tt.sgIDs, got, tt.want)
+		}
+	}
+}
+
+func TestMapRuleEndpoint(t *testing.T) {
+	resolverSync := &ResolverSync{endpointMap: map[string]string{
+		"rslvr-out-a": "rslvr-out-1",
+		// not created in dry run.
+		"rslvr-out-b": aws.StringValue(dryRunID("rslvr-out-b")),
+	}}
+
+	tests := []struct {
+		endpointID *string
+		want       *string
+		wantOK     bool
+	}{
+		// system / forward rule without endpoint.
+		{nil, nil, true},
+		{aws.String("rslvr-out-a"), aws.String("rslvr-out-1"), true},
+		{aws.String("rslvr-out-b"), dryRunID("rslvr-out-b"), true},
+		// endpoint of other VPC.
+		{aws.String("rslvr-out-c"), nil, false},
+	}
+
+	for _, tt := range tests {
+		rule := route53resolver.ResolverRule{Id: aws.String("rslvr-rr-1"), ResolverEndpointId: tt.endpointID}
+		got, ok := resolverSync.mapRuleEndpoint(rule)
+		if ok != tt.wantOK || aws.StringValue(got) != aws.StringValue(tt.want) {
+			t.Errorf("mapRuleEndpoint(%s) = %s, %t, want %s, %t", aws.StringValue(tt.endpointID),
+				aws.StringValue(got), ok, aws.StringValue(tt.want), tt.wantOK)
+		}
+	}
+}
+
+func TestMapAssociation(t *testing.T) {
+	resolverSync := &ResolverSync{
+		config: &ResolverConfig{VPCMap: map[string]string{"vpc-b": "vpc-2"}},
+		ruleMap: map[string]string{
+			"rslvr-rr-a": "rslvr-rr-1",
+			"rslvr-rr-b": aws.StringValue(dryRunID("rslvr-rr-b")),
+		},
+	}
+	resolverSync.buildVPCMap(&awsAuth{VIPCID: "vpc-a"}, &awsAuth{VIPCID: "vpc-1"})
+
+	tests := []struct {
+		ruleID string
+		vpcID  string
+		want   [2]string
+		wantOK bool
+	}{
+		{"rslvr-rr-a", "vpc-a", [2]string{"rslvr-rr-1", "vpc-1"}, true},
+		{"rslvr-rr-a", "vpc-b", [2]string{"rslvr-rr-1", "vpc-2"}, true},
+		{"rslvr-rr-b", "vpc-a", [2]string{aws.StringValue(dryRunID("rslvr-rr-b")), "vpc-1"}, true},
+		// rule not migrated, e.g. shared rule.
+		{"rslvr-rr-c", "vpc-a", [2]string{}, false},
+		{"rslvr-rr-a", "vpc-c", [2]string{}, false},
+	}
+
+	for _, tt := range tests {
+		association := route53resolver.ResolverRuleAssociation{
+			Id:             aws.String("rslvr-rrassoc-1"),
+			ResolverRuleId: aws.String(tt.ruleID),
+			VPCId:          aws.String(tt.vpcID),
+		}
+		ruleID, vpcID, ok := resolverSync.mapAssociation(association)
+		if ok != tt.wantOK || [2]string{ruleID, vpcID} != tt.want {
+			t.Errorf("mapAssociation(%s, %s) = %s, %s, %t, want %v, %t", tt.ruleID, tt.vpcID, ruleID, vpcID, ok, tt.want, tt.wantOK)
+		}
+	}
+}
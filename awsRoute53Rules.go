@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// normalizeDNSName lower case the name, decode the wildcard escape and add the trailing dot.
+func normalizeDNSName(name string) string {
+	name = strings.ToLower(strings.Replace(name, `\052`, "*", -1))
+	if !strings.HasSuffix(name, ".") {
+		name = name + "."
+	}
+	return name
+}
+
+// trimHostedZoneID strip "/hostedzone/" from hosted zone id.
+func trimHostedZoneID(id string) string {
+	return strings.TrimPrefix(id, "/hostedzone/")
+}
+
+func matchRecordType(types []string, rrType route53.RRType) bool {
+	for _, t := range types {
+		if strings.EqualFold(t, string(rrType)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRecordName(patterns []string, name string) bool {
+	name = strings.TrimSuffix(normalizeDNSName(name), ".")
+
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.ToLower(p), ".")
+		ok, err := path.Match(p, name)
+		if err != nil {
+			log.Fatalf("Route53 name pattern %q error, %v", p, err)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r53sync *route53Sync) filterRecords() {
+	if r53sync.config == nil {
+		return
+	}
+
+	cfg := r53sync.config
+	rrSets := []route53.ResourceRecordSet{}
+
+	for _, v := range r53sync.srcRecordListRes.ResourceRecordSets {
+		name := aws.StringValue(v.Name)
+
+		switch {
+		case len(cfg.IncludeTypes) > 0 && !matchRecordType(cfg.IncludeTypes, v.Type):
+			continue
+		case matchRecordType(cfg.ExcludeTypes, v.Type):
+			continue
+		case len(cfg.IncludeNames) > 0 && !matchRecordName(cfg.IncludeNames, name):
+			continue
+		case matchRecordName(cfg.ExcludeNames, name):
+			log.Printf("Exclude Record: %s(%s)", name, v.Type)
+			continue
+		}
+
+		rrSets = append(rrSets, v)
+	}
+
+	log.Printf("Filter Record: %d/%d", len(rrSets), len(r53sync.srcRecordListRes.ResourceRecordSets))
+
+	r53sync.srcRecordListRes.ResourceRecordSets = rrSets
+}
+
+// rewriteSuffix replace zone suffix from src to dst, if name in src zone.
+func rewriteSuffix(name, srcZone, dstZone string) (string, bool) {
+	n := normalizeDNSName(name)
+
+	if n != srcZone && !strings.HasSuffix(n, "."+srcZone) {
+		return name, false
+	}
+
+	return strings.TrimSuffix(n, srcZone) + dstZone, true
+}
+
+func (r53sync *route53Sync) rewriteRecords() {
+	srcZone := normalizeDNSName(aws.StringValue(r53sync.srcHostedZone.Name))
+	dstZone := normalizeDNSName(aws.StringValue(r53sync.dstHostedZone.Name))
+	srcZoneID := trimHostedZoneID(aws.StringValue(r53sync.srcHostedZone.Id))
+	dstZoneID := trimHostedZoneID(aws.StringValue(r53sync.dstHostedZone.Id))
+
+	rename := srcZone != dstZone
+	if rename {
+		log.Printf("Rewrite Record Suffix: %s -> %s", srcZone, dstZone)
+	}
+
+	for i, v := range r53sync.srcRecordListRes.ResourceRecordSets {
+		if rename {
+			if newName, ok := rewriteSuffix(aws.StringValue(v.Name), srcZone, dstZone); ok {
+				r53sync.report(v, "Name", aws.StringValue(v.Name), newName)
+				v.Name = aws.String(newName)
+			}
+
+			if v.Type == route53.RRTypeCname {
+				rrs := make([]route53.ResourceRecord, len(v.ResourceRecords))
+				for ii, rr := range v.ResourceRecords {
+					if newValue, ok := rewriteSuffix(aws.StringValue(rr.Value), srcZone, dstZone); ok {
+						r53sync.report(v, "Value", aws.StringValue(rr.Value), newValue)
+						rr.Value = aws.String(newValue)
+					}
+					rrs[ii] = rr
+				}
+				v.ResourceRecords = rrs
+			}
+		}
+
+		// alias to record in same zone, point to new zone.
+		if v.AliasTarget != nil && trimHostedZoneID(aws.StringValue(v.AliasTarget.HostedZoneId)) == srcZoneID {
+			alias := *v.AliasTarget
+			alias.HostedZoneId = aws.String(dstZoneID)
+			if rename {
+				if newName, ok := rewriteSuffix(aws.StringValue(alias.DNSName), srcZone, dstZone); ok {
+					r53sync.report(v, "AliasTarget", aws.StringValue(alias.DNSName), newName)
+					alias.DNSName = aws.String(newName)
+				}
+			}
+			v.AliasTarget = &alias
+		}
+
+		r53sync.srcRecordListRes.ResourceRecordSets[i] = v
+	}
+}
+
+// RecordChangeReport ...
+type RecordChangeReport struct {
+	Name  string `json:"Name"`
+	Type  string `json:"Type"`
+	Field string `json:"Field"`
+	Old   string `json:"Old"`
+	New   string `json:"New"`
+}
+
+func (r53sync *route53Sync) report(rrSet route53.ResourceRecordSet, field, oldValue, newValue string) {
+	r53sync.reports = append(r53sync.reports, RecordChangeReport{
+		Name:  aws.StringValue(rrSet.Name),
+		Type:  string(rrSet.Type),
+		Field: field,
+		Old:   oldValue,
+		New:   newValue,
+	})
+}
+
+func (r53sync *route53Sync) transformTTL(rrSet route53.ResourceRecordSet) *int64 {
+	// alias record has no ttl.
+	if rrSet.TTL == nil {
+		return nil
+	}
+
+	rule, ok := r53sync.config.TTL[string(rrSet.Type)]
+	if !ok {
+		rule, ok = r53sync.config.TTL["*"]
+	}
+	if !ok {
+		return rrSet.TTL
+	}
+
+	ttl := aws.Int64Value(rrSet.TTL)
+	if rule.Override > 0 {
+		ttl = rule.Override
+	}
+	if rule.Max > 0 && ttl > rule.Max {
+		ttl = rule.Max
+	}
+
+	if ttl != aws.Int64Value(rrSet.TTL) {
+		r53sync.report(rrSet, "TTL", strconv.FormatInt(aws.Int64Value(rrSet.TTL), 10), strconv.FormatInt(ttl, 10))
+	}
+
+	return aws.Int64(ttl)
+}
+
+// newValueReplacer longest old value first, overlapping values (10.0.0.1, 10.0.0.10) same result every run.
+func newValueReplacer(valueMap map[string]string) *strings.Replacer {
+	oldValues := make([]string, 0, len(valueMap))
+	for oldValue := range valueMap {
+		oldValues = append(oldValues, oldValue)
+	}
+	sort.Slice(oldValues, func(i, j int) bool {
+		if len(oldValues[i]) != len(oldValues[j]) {
+			return len(oldValues[i]) > len(oldValues[j])
+		}
+		return oldValues[i] < oldValues[j]
+	})
+
+	pairs := make([]string, 0, len(valueMap)*2)
+	for _, oldValue := range oldValues {
+		pairs = append(pairs, oldValue, valueMap[oldValue])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func substituteValue(rrType route53.RRType, value string, valueMap map[string]string) string {
+	switch rrType {
+	case route53.RRTypeTxt:
+		// txt value is quoted string, replace the part of value.
+		value = newValueReplacer(valueMap).Replace(value)
+	case route53.RRTypeCname:
+		for oldValue, newValue := range valueMap {
+			if normalizeDNSName(oldValue) == normalizeDNSName(value) {
+				return newValue
+			}
+		}
+	default:
+		if newValue, ok := valueMap[value]; ok {
+			return newValue
+		}
+	}
+	return value
+}
+
+func (r53sync *route53Sync) transformValues(rrSet route53.ResourceRecordSet) []route53.ResourceRecord {
+	valueMap, ok := r53sync.config.Values[string(rrSet.Type)]
+	if !ok || len(rrSet.ResourceRecords) == 0 {
+		return rrSet.ResourceRecords
+	}
+
+	switch rrSet.Type {
+	case route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname, route53.RRTypeTxt:
+	default:
+		log.Printf("Record Type %s Unsupported Value Substitution, Ignore.", rrSet.Type)
+		return rrSet.ResourceRecords
+	}
+
+	rrs := make([]route53.ResourceRecord, len(rrSet.ResourceRecords))
+	for i, rr := range rrSet.ResourceRecords {
+		value := substituteValue(rrSet.Type, aws.StringValue(rr.Value), valueMap)
+		if value != aws.StringValue(rr.Value) {
+			r53sync.report(rrSet, "Value", aws.StringValue(rr.Value), value)
+			rr.Value = aws.String(value)
+		}
+		rrs[i] = rr
+	}
+
+	return rrs
+}
+
+func (r53sync *route53Sync) writeReport() {
+	if len(r53sync.reports) == 0 {
+		log.Print("No Record Rewritten.")
+		return
+	}
+
+	for _, r := range r53sync.reports {
+		log.Printf("Rewrite Record: %s(%s) %s: %q -> %q", r.Name, r.Type, r.Field, r.Old, r.New)
+	}
+
+	if len(r53sync.config.ReportFile) == 0 {
+		return
+	}
+
+	buff, err := json.MarshalIndent(r53sync.reports, "", "  ")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = ioutil.WriteFile(r53sync.config.ReportFile, buff, 0644)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Report File: %s", r53sync.config.ReportFile)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+func TestNormalizeDNSName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"example.com", "example.com."},
+		{"Example.COM.", "example.com."},
+		{`\052.example.com.`, "*.example.com."},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeDNSName(tt.name); got != tt.want {
+			t.Errorf("normalizeDNSName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRecordName(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{[]string{"*.internal.old.com"}, "api.internal.old.com.", true},
+		{[]string{"*.internal.old.com."}, "API.Internal.Old.Com", true},
+		{[]string{"*.internal.old.com"}, "internal.old.com.", false},
+		{[]string{"test.internal.old.com"}, `\052.internal.old.com.`, false},
+		{nil, "api.internal.old.com.", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchRecordName(tt.patterns, tt.name); got != tt.want {
+			t.Errorf("matchRecordName(%v, %q) = %v, want %v", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteSuffix(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		rewrite bool
+	}{
+		{"internal.old.com.", "internal.new.com.", true},
+		{"api.internal.old.com.", "api.internal.new.com.", true},
+		{"API.Internal.Old.Com", "api.internal.new.com.", true},
+		{`\052.internal.old.com.`, "*.internal.new.com.", true},
+		// suffix only at label boundary.
+		{"myinternal.old.com.", "myinternal.old.com.", false},
+		{"other.com.", "other.com.", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := rewriteSuffix(tt.name, "internal.old.com.", "internal.new.com.")
+		if got != tt.want || ok != tt.rewrite {
+			t.Errorf("rewriteSuffix(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.rewrite)
+		}
+	}
+}
+
+func TestSubstituteValue(t *testing.T) {
+	valueMap := map[string]string{
+		"10.0.0.1":    "10.1.0.1",
+		"10.0.0.10":   "10.1.0.10",
+		"old.elb.com": "new.elb.com",
+	}
+
+	tests := []struct {
+		rrType route53.RRType
+		value  string
+		want   string
+	}{
+		{route53.RRTypeA, "10.0.0.10", "10.1.0.10"},
+		{route53.RRTypeA, "10.0.0.2", "10.0.0.2"},
+		{route53.RRTypeCname, "OLD.elb.com.", "new.elb.com"},
+		// overlapping keys, longest first.
+		{route53.RRTypeTxt, `"v=spf1 ip4:10.0.0.10 ip4:10.0.0.1 -all"`, `"v=spf1 ip4:10.1.0.10 ip4:10.1.0.1 -all"`},
+		{route53.RRTypeTxt, `"nothing"`, `"nothing"`},
+	}
+
+	for _, tt := range tests {
+		// map order is random, run repeatedly.
+		for i := 0; i < 20; i++ {
+			if got := substituteValue(tt.rrType, tt.value, valueMap); got != tt.want {
+				t.Fatalf("substituteValue(%s, %q) = %q, want %q", tt.rrType, tt.value, got, tt.want)
+			}
+		}
+	}
+}
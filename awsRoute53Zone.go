@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+const r53QueryLogPolicyName = "route53-query-logging-policy"
+
+func getAccountID(account *awsAuth) string {
+	svc := sts.New(newAWSConfig(account))
+
+	var result *sts.GetCallerIdentityResponse
+	err := limitedCall(serviceSTS, func(ctx context.Context) (err error) {
+		result, err = svc.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return aws.StringValue(result.Account)
+}
+
+// mapLogGroupARN replace the account id of log group arn,
+// arn:aws:logs:us-east-1:111111111111:log-group:/aws/route53/example.com:*
+func mapLogGroupARN(arn, accountID string) string {
+	fields := strings.SplitN(arn, ":", 6)
+	if len(fields) != 6 {
+		log.Fatalf("Unknown Log Group ARN: %s", arn)
+	}
+
+	fields[4] = accountID
+	return strings.Join(fields, ":")
+}
+
+func newLogsSVC(account *awsAuth, region string) *cloudwatchlogs.Client {
+	cfg := newAWSConfig(account)
+	cfg.Region = region
+
+	return cloudwatchlogs.New(cfg)
+}
+
+// parseLogGroupARN region, account id and log group name of log group arn.
+func parseLogGroupARN(arn string) (region string, accountID string, logGroupName string, err error) {
+	fields := strings.SplitN(arn, ":", 7)
+	if len(fields) != 7 || fields[0] != "arn" || fields[5] != "log-group" {
+		return "", "", "", fmt.Errorf("Unknown Log Group ARN: %s", arn)
+	}
+
+	return fields[3], fields[4], strings.TrimSuffix(fields[6], ":*"), nil
+}
+
+func ensureQueryLogGroup(account *awsAuth, arn string) error {
+	region, accountID, logGroupName, err := parseLogGroupARN(arn)
+	if err != nil {
+		return err
+	}
+
+	if dryRunSimulate("Create Log Group %s(%s) And Put Resource Policy %s", logGroupName, region, r53QueryLogPolicyName) {
+		return nil
+	}
+
+	svc := newLogsSVC(account, region)
+
+	err = awsCall(serviceLogs, "Create Log Group", logGroupName, []string{cloudwatchlogs.ErrCodeResourceAlreadyExistsException}, func(ctx context.Context) error {
+		_, err := svc.CreateLogGroupRequest(&cloudwatchlogs.CreateLogGroupInput{
+			LogGroupName: aws.String(logGroupName),
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Log Group: %s(%s)", logGroupName, region)
+
+	// route53 need permission to write the log group.
+	policy := fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Sid":"Route53LogsToCloudWatchLogs","Effect":"Allow",`+
+		`"Principal":{"Service":["route53.amazonaws.com"]},"Action":["logs:CreateLogStream","logs:PutLogEvents"],`+
+		`"Resource":"arn:aws:logs:%s:%s:log-group:/aws/route53/*"}]}`, region, accountID)
+
+	err = awsCall(serviceLogs, "Put Log Resource Policy", r53QueryLogPolicyName, nil, func(ctx context.Context) error {
+		_, err := svc.PutResourcePolicyRequest(&cloudwatchlogs.PutResourcePolicyInput{
+			PolicyName:     aws.String(r53QueryLogPolicyName),
+			PolicyDocument: aws.String(policy),
+		}).Send(ctx)
+		return err
+	})
+	return err
+}
+
+func (r53sync *route53Sync) syncQueryLogging(src *awsAuth, dst *awsAuth) {
+	if aws.BoolValue(r53sync.srcHostedZone.Config.PrivateZone) {
+		return
+	}
+
+	srcSVC := newRoute53SVC(src)
+
+	var result *route53.ListQueryLoggingConfigsResponse
+	err := limitedCall(serviceRoute53, func(ctx context.Context) (err error) {
+		result, err = srcSVC.ListQueryLoggingConfigsRequest(&route53.ListQueryLoggingConfigsInput{
+			HostedZoneId: aws.String(trimHostedZoneID(aws.StringValue(r53sync.srcHostedZone.Id))),
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if len(result.QueryLoggingConfigs) == 0 {
+		log.Print("Not Found Query Logging Config.")
+		return
+	}
+
+	dstSVC := newRoute53SVC(dst)
+
+	for _, qlc := range result.QueryLoggingConfigs {
+		arn := r53sync.config.QueryLogGroupARN
+		if len(arn) == 0 {
+			arn = mapLogGroupARN(aws.StringValue(qlc.CloudWatchLogsLogGroupArn), getAccountID(dst))
+		}
+
+		if err := ensureQueryLogGroup(dst, arn); err != nil {
+			log.Fatalln(err)
+		}
+
+		if dryRunSimulate("Create Query Logging Config %s", arn) {
+			continue
+		}
+
+		err := awsCall(serviceRoute53, "Create Query Logging Config", arn, []string{route53.ErrCodeQueryLoggingConfigAlreadyExists}, func(ctx context.Context) error {
+			_, err := dstSVC.CreateQueryLoggingConfigRequest(&route53.CreateQueryLoggingConfigInput{
+				CloudWatchLogsLogGroupArn: aws.String(arn),
+				HostedZoneId:              aws.String(trimHostedZoneID(aws.StringValue(r53sync.dstHostedZone.Id))),
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		log.Printf("Query Logging Config: %s", arn)
+	}
+}
+
+// getHostedZoneTags tags of hosted zone.
+func getHostedZoneTags(account *awsAuth, zoneID string) []route53.Tag {
+	svc := newRoute53SVC(account)
+
+	var result *route53.ListTagsForResourceResponse
+	err := limitedCall(serviceRoute53, func(ctx context.Context) (err error) {
+		result, err = svc.ListTagsForResourceRequest(&route53.ListTagsForResourceInput{
+			ResourceId:   aws.String(trimHostedZoneID(zoneID)),
+			ResourceType: route53.TagResourceTypeHostedzone,
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if result.ResourceTagSet == nil {
+		return nil
+	}
+	return result.ResourceTagSet.Tags
+}
+
+func (r53sync *route53Sync) syncZoneTags(src *awsAuth, dst *awsAuth, tagsConfig []Tag) {
+	tags := append([]route53.Tag{}, getHostedZoneTags(src, aws.StringValue(r53sync.srcHostedZone.Id))...)
+
+	for _, v := range tagsConfig {
+		tags = append(tags, route53.Tag{
+			Key:   aws.String(v.Key),
+			Value: aws.String(v.Value),
+		})
+	}
+
+	if len(tags) == 0 {
+		return
+	}
+
+	if dryRunSimulate("Set Hosted Zone Tags: %d", len(tags)) {
+		return
+	}
+
+	dstSVC := newRoute53SVC(dst)
+
+	err := awsCall(serviceRoute53, "Set Hosted Zone Tags", aws.StringValue(r53sync.dstHostedZone.Id), nil, func(ctx context.Context) error {
+		_, err := dstSVC.ChangeTagsForResourceRequest(&route53.ChangeTagsForResourceInput{
+			AddTags:      tags,
+			ResourceId:   aws.String(trimHostedZoneID(aws.StringValue(r53sync.dstHostedZone.Id))),
+			ResourceType: route53.TagResourceTypeHostedzone,
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Set Hosted Zone Tags: %d", len(tags))
+}
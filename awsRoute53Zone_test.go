@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseLogGroupARN(t *testing.T) {
+	tests := []struct {
+		arn       string
+		region    string
+		accountID string
+		name      string
+		wantErr   bool
+	}{
+		{"arn:aws:logs:us-east-1:111111111111:log-group:/aws/route53/example.com:*", "us-east-1", "111111111111", "/aws/route53/example.com", false},
+		{"arn:aws:logs:us-east-1:111111111111:log-group:/aws/route53/example.com", "us-east-1", "111111111111", "/aws/route53/example.com", false},
+		{"arn:aws:logs:us-east-1:111111111111", "", "", "", true},
+		{"/aws/route53/example.com", "", "", "", true},
+		{"arn:aws:s3:us-east-1:111111111111:bucket:example", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		region, accountID, name, err := parseLogGroupARN(tt.arn)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLogGroupARN(%q) error = %v, want error %t", tt.arn, err, tt.wantErr)
+			continue
+		}
+		if region != tt.region || accountID != tt.accountID || name != tt.name {
+			t.Errorf("parseLogGroupARN(%q) = %q, %q, %q, want %q, %q, %q", tt.arn, region, accountID, name, tt.region, tt.accountID, tt.name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// newTestAWSConfig sdk config of test server, sdk retry disabled.
+func newTestAWSConfig(url string) aws.Config {
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = newCredentials(&awsAuth{AccessKey: "AKID", SecretKey: "SECRET"})
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(url)
+	cfg.Retryer = aws.NoOpRetryer{}
+	return cfg
+}
+
+func TestListRecordSets(t *testing.T) {
+	// start record name: record, next page.
+	pages := map[string][2]string{
+		"":               {"a.example.com.", `<NextRecordName>b.example.com.</NextRecordName><NextRecordType>A</NextRecordType><IsTruncated>true</IsTruncated>`},
+		"b.example.com.": {"b.example.com.", `<IsTruncated>false</IsTruncated>`},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		page, ok := pages[name]
+		if !ok {
+			t.Errorf("unexpected start record name %q", name)
+		}
+		if name == "b.example.com." && r.URL.Query().Get("type") != "A" {
+			t.Errorf("start record type = %q, want A", r.URL.Query().Get("type"))
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+<ResourceRecordSets><ResourceRecordSet><Name>%s</Name><Type>A</Type><TTL>300</TTL>
+<ResourceRecords><ResourceRecord><Value>10.0.0.1</Value></ResourceRecord></ResourceRecords></ResourceRecordSet></ResourceRecordSets>
+<MaxItems>1</MaxItems>%s</ListResourceRecordSetsResponse>`, page[0], page[1])
+	}))
+	defer server.Close()
+
+	res, err := listRecordSets(route53.New(newTestAWSConfig(server.URL)), "Z1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, rr := range res.ResourceRecordSets {
+		names = append(names, aws.StringValue(rr.Name))
+	}
+	if len(names) != 2 || names[0] != "a.example.com." || names[1] != "b.example.com." {
+		t.Errorf("records = %v, want [a.example.com. b.example.com.]", names)
+	}
+	if aws.BoolValue(res.IsTruncated) {
+		t.Error("merged response truncated")
+	}
+}
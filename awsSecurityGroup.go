@@ -3,40 +3,53 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"reflect"
 	"runtime"
-	"text/template"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/awserr"
-	"github.com/aws/aws-sdk-go-v2/aws/external"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 )
 
 const (
 	sgSelf        = "sg-self"
 	sgDefaultName = "default"
+
+	errCodePermissionDuplicate = "InvalidPermission.Duplicate"
+	errCodePermissionNotFound  = "InvalidPermission.NotFound"
+	errCodeGroupDuplicate      = "InvalidGroup.Duplicate"
 )
 
 // BuildSGMapType ...
 type BuildSGMapType map[*string][]ec2.IpPermission
 
-// SGIdNameMapType ...
-type SGIdNameMapType map[string]string
+// sgKey security group name is unique within VPC.
+type sgKey struct {
+	VpcID string
+	Name  string
+}
+
+// SGIdKeyMapType ...
+type SGIdKeyMapType map[string]sgKey
+
+// SGKeyIDMapType ...
+type SGKeyIDMapType map[sgKey]string
 
 var (
-	// ID: Name
-	sgIDMameMap = make(SGIdNameMapType)
+	// source ID: (destination VPC, Name)
+	sgIDMameMap = make(SGIdKeyMapType)
+
+	// (destination VPC, Name): ID
+	newSGNameIDMap = make(SGKeyIDMapType)
+	sgMapLock      sync.Mutex
 
-	// Name: ID
-	newSGNameIDMap = make(SGIdNameMapType)
+	// source account id, user id of same account references.
+	sourceOwnerID string
 
 	ipps  = make(BuildSGMapType)
 	ippes = make(BuildSGMapType)
@@ -45,34 +58,7 @@ var (
 )
 
 func newSVC(account *awsAuth) *ec2.Client {
-	cfg, err := external.LoadDefaultAWSConfig(
-		external.WithCredentialsProvider{
-			CredentialsProvider: aws.StaticCredentialsProvider{
-				Value: aws.Credentials{
-					AccessKeyID:     account.AccessKey,
-					SecretAccessKey: account.SecretKey,
-					Source:          "config file",
-					// SessionToken:    "",
-				},
-			},
-		},
-	)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to load config, %v", err)
-		os.Exit(1)
-	}
-
-	cfg.Region = account.Region
-
-	// Credentials retrieve will be called automatically internally to the SDK
-	// service clients created with the cfg value.
-	_, err = cfg.Credentials.Retrieve(context.Background())
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to get credentials, %v", err)
-		os.Exit(1)
-	}
-
-	return ec2.New(cfg)
+	return ec2.New(newAWSConfig(account))
 }
 
 // SecurityGroupSyncGO ...
@@ -80,8 +66,13 @@ func SecurityGroupSyncGO(awsAccount *AWSAccount) {
 	var awssync AWSSync
 
 	awssync.perfixListMap = make(map[string]*PerfixList)
-	awssync.sourceSGLists = GetSGList(&awsAccount.Source)
+	awssync.sourceSGLists = filterSGList(getSourceSGList(awsAccount), &awsAccount.SecurityGroup)
+	awssync.sourceSGLists = awsAccount.SecurityGroup.Rename.renameSGList(awssync.sourceSGLists)
 	awssync.tagsConfig = awsAccount.Tags
+	awssync.sgConfig = &awsAccount.SecurityGroup
+
+	awssync.cidr = newCIDRTranslator(&awsAccount.CIDR, &awsAccount.Source, &awsAccount.Destination, awsAccount.SecurityGroup.VPCIDs, awsAccount.SecurityGroup.VPCMap)
+	awssync.sourceSGLists = awssync.cidr.translateSGList(awssync.sourceSGLists)
 
 	awssync.GetPerfixLists(&awsAccount.Source)
 
@@ -91,25 +82,16 @@ func SecurityGroupSyncGO(awsAccount *AWSAccount) {
 	case updateMode:
 		awssync.UpdateSGList(&awsAccount.Destination)
 	default:
-		awssync.CreateAndSyncSGList(&awsAccount.Destination, &awsAccount.DryRun)
+		awssync.CreateAndSyncSGList(&awsAccount.Destination)
 	}
+
+	awssync.cidr.writeReport()
 }
 
 // ExportSecurityGroupRule ...
-func ExportSecurityGroupRule(account *awsAuth, filePath string, tf bool, tags *[]Tag) {
-	var (
-		err  error
-		buff []byte
-	)
-
+func ExportSecurityGroupRule(account *awsAuth, filePath string, tf bool, tags *[]Tag, tfOption TerraformOption, filter *SecurityGroupConfig) {
 	fileName := "SecurityGroup-" + time.Now().Format("20060102150405")
 
-	if tf {
-		fileName = fileName + ".tf"
-	} else {
-		fileName = fileName + ".json"
-	}
-
 	if len(filePath) > 0 {
 		filePath = filepath.Clean(filePath)
 
@@ -126,48 +108,33 @@ func ExportSecurityGroupRule(account *awsAuth, filePath string, tf bool, tags *[
 		filePath = filePath + splitWord
 	}
 
-	sgList := GetSGList(account)
+	sgList := filterSGList(GetSGListByVPC(account, filterVPCIDs(account, filter)...), filter)
 
 	if tf {
-		buff, err = ioutil.ReadAll(convertTf(sgList, tags))
-		if err != nil {
-			log.Fatalln(err)
-			return
-		}
-	} else {
-		buff, err = json.Marshal(sgList)
-		if err != nil {
-			log.Fatalln(err)
-			return
-		}
-	}
-
-	err = ioutil.WriteFile(filePath+fileName, buff, 0644)
-	if err != nil {
-		log.Fatalln(err)
+		tfExport := newTerraformExport(tags, tfOption)
+		buf := convertTf(tfExport, sgList)
+		tfExport.write(buf, filePath+fileName+tfExport.extension())
 		return
 	}
 
-	log.Printf("Output File: %s, Export Done.", filePath+fileName)
+	newSnapshot(account, sgList).write(filePath + fileName + ".json")
 }
 
-// RestoreSecurityGroupRule ...
-func RestoreSecurityGroupRule(account *awsAuth, filePath string) {
-	restoreContent, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		log.Fatalln(err)
-	}
+// RestoreSecurityGroupRule restore by group id, or byName match groups by name within account VPC.
+func RestoreSecurityGroupRule(account *awsAuth, filePath string, byName bool, filter *SecurityGroupConfig) {
+	snapshot := loadSnapshot(filePath)
+	snapshot.filter(filter)
 
-	var ec2SecurityGroups []ec2.SecurityGroup
-	err = json.Unmarshal(restoreContent, &ec2SecurityGroups)
-	if err != nil {
-		log.Fatalln(err)
-		return
+	if byName {
+		snapshot.mapByName(account)
+	} else if len(snapshot.Metadata.Region) > 0 && snapshot.Metadata.Region != account.Region {
+		log.Printf("Snapshot Region %s Not Match Account Region %s.", snapshot.Metadata.Region, account.Region)
 	}
 
 	svc := newSVC(account)
 
-	sgList := GetSGList(account)
+	// snapshot groups by id, any VPC.
+	sgList := GetSGListByVPC(account)
 
 	oldSGListMap := make(map[string]ec2.SecurityGroup)
 
@@ -175,15 +142,13 @@ func RestoreSecurityGroupRule(account *awsAuth, filePath string) {
 		oldSGListMap[aws.StringValue(oldSG.GroupId)] = oldSG
 	}
 
-	for _, sg := range ec2SecurityGroups {
+	for _, snapshotSG := range snapshot.SecurityGroups {
+		sg := ec2.SecurityGroup{GroupId: aws.String(snapshotSG.GroupID)}
+		sg.IpPermissions, sg.IpPermissionsEgress = snapshotSG.ipPermissions()
 
 		oldIpp := oldSGListMap[aws.StringValue(sg.GroupId)].IpPermissions
 		if len(oldIpp) > 0 {
-			reqRevok := svc.RevokeSecurityGroupIngressRequest(&ec2.RevokeSecurityGroupIngressInput{
-				GroupId:       sg.GroupId,
-				IpPermissions: oldIpp,
-			})
-			_, err := reqRevok.Send(context.Background())
+			err := revokeSGRules(svc, sg.GroupId, "ingress", oldIpp)
 			if err != nil {
 				exitErrorf("Unable to revoke security group %q Ingress, %v", *sg.GroupId, err)
 			}
@@ -191,33 +156,21 @@ func RestoreSecurityGroupRule(account *awsAuth, filePath string) {
 
 		oldIppe := oldSGListMap[aws.StringValue(sg.GroupId)].IpPermissionsEgress
 		if len(oldIppe) > 0 {
-			reqRevok := svc.RevokeSecurityGroupEgressRequest(&ec2.RevokeSecurityGroupEgressInput{
-				GroupId:       sg.GroupId,
-				IpPermissions: oldIppe,
-			})
-			_, err := reqRevok.Send(context.Background())
+			err := revokeSGRules(svc, sg.GroupId, "egress", oldIppe)
 			if err != nil {
 				exitErrorf("Unable to revoke security group %q Egress, %v", *sg.GroupId, err)
 			}
 		}
 
 		if len(sg.IpPermissions) > 0 {
-			reqAuthor := svc.AuthorizeSecurityGroupIngressRequest(&ec2.AuthorizeSecurityGroupIngressInput{
-				GroupId:       sg.GroupId,
-				IpPermissions: sg.IpPermissions,
-			})
-			_, err := reqAuthor.Send(context.Background())
+			err := authorizeSGRules(svc, sg.GroupId, "ingress", sg.IpPermissions)
 			if err != nil {
 				exitErrorf("Unable to authorize security group %q Ingress, %v", *sg.GroupId, err)
 			}
 		}
 
 		if len(sg.IpPermissionsEgress) > 0 {
-			reqAuthor := svc.AuthorizeSecurityGroupEgressRequest(&ec2.AuthorizeSecurityGroupEgressInput{
-				GroupId:       sg.GroupId,
-				IpPermissions: sg.IpPermissionsEgress,
-			})
-			_, err := reqAuthor.Send(context.Background())
+			err := authorizeSGRules(svc, sg.GroupId, "egress", sg.IpPermissionsEgress)
 			if err != nil {
 				exitErrorf("Unable to authorize security group %q Egress, %v", *sg.GroupId, err)
 			}
@@ -233,10 +186,13 @@ func RestoreSecurityGroupRule(account *awsAuth, filePath string) {
 func GetFilterSGListByNames(account *awsAuth, names ...string) []ec2.SecurityGroup {
 	svc := newSVC(account)
 
-	req := svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{
-		GroupNames: names,
+	var result *ec2.DescribeSecurityGroupsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{
+			GroupNames: names,
+		}).Send(ctx)
+		return err
 	})
-	result, err := req.Send(context.Background())
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -257,10 +213,13 @@ func GetFilterSGListByNames(account *awsAuth, names ...string) []ec2.SecurityGro
 func GetFilterSGListByIds(account *awsAuth, groupIds ...string) []ec2.SecurityGroup {
 	svc := newSVC(account)
 
-	req := svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{
-		GroupIds: groupIds,
+	var result *ec2.DescribeSecurityGroupsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{
+			GroupIds: groupIds,
+		}).Send(ctx)
+		return err
 	})
-	result, err := req.Send(context.Background())
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -277,12 +236,33 @@ func GetFilterSGListByIds(account *awsAuth, groupIds ...string) []ec2.SecurityGr
 	return result.SecurityGroups
 }
 
-// GetSGList ...
+// GetSGList list security groups in account VPCID, all if VPCID not set.
 func GetSGList(account *awsAuth) []ec2.SecurityGroup {
+	if len(account.VIPCID) == 0 {
+		return GetSGListByVPC(account)
+	}
+	return GetSGListByVPC(account, account.VIPCID)
+}
+
+// GetSGListByVPC list security groups in vpcIDs, all if vpcIDs empty.
+func GetSGListByVPC(account *awsAuth, vpcIDs ...string) []ec2.SecurityGroup {
 	svc := newSVC(account)
 
-	req := svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{})
-	result, err := req.Send(context.Background())
+	input := &ec2.DescribeSecurityGroupsInput{}
+	if len(vpcIDs) > 0 {
+		input.Filters = []ec2.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: vpcIDs,
+			},
+		}
+	}
+
+	var result *ec2.DescribeSecurityGroupsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSecurityGroupsRequest(input).Send(ctx)
+		return err
+	})
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -295,10 +275,110 @@ func GetSGList(account *awsAuth) []ec2.SecurityGroup {
 		exitErrorf("Unable to get descriptions for security groups, %v", err)
 	}
 
-	log.Println("Successfully get security group list")
+	log.Printf("Successfully get security group list, VPC: %v", vpcIDs)
 	return result.SecurityGroups
 }
 
+// filterVPCIDs filter VPCIDs, or account VPCID.
+func filterVPCIDs(account *awsAuth, filter *SecurityGroupConfig) []string {
+	if filter != nil && len(filter.VPCIDs) > 0 {
+		return filter.VPCIDs
+	}
+	if len(account.VIPCID) > 0 {
+		return []string{account.VIPCID}
+	}
+	return nil
+}
+
+// getSourceSGList source security groups of Source VPCID, or SecurityGroup VPCIDs when multiple source VPCs.
+func getSourceSGList(awsAccount *AWSAccount) []ec2.SecurityGroup {
+	return GetSGListByVPC(&awsAccount.Source, filterVPCIDs(&awsAccount.Source, &awsAccount.SecurityGroup)...)
+}
+
+// destinationVPC source VPC to destination VPC, by SecurityGroup VPCMap, default Destination VPCID.
+func (awssync *AWSSync) destinationVPC(account *awsAuth, srcVPCID string) string {
+	if dstVPCID, ok := awssync.sgConfig.VPCMap[srcVPCID]; ok {
+		return dstVPCID
+	}
+	return account.VIPCID
+}
+
+// mapSourceSGList record source group id to destination (VPC, Name), exit if two source groups
+// map to the same destination (VPC, Name), except default groups of multiple source VPCs which
+// share the destination default group, rules merged. Return groups to sync and destination VPCs.
+func (awssync *AWSSync) mapSourceSGList(account *awsAuth, sgList []ec2.SecurityGroup) ([]ec2.SecurityGroup, []string) {
+	index := make(map[sgKey]int)
+	newSGList := []ec2.SecurityGroup{}
+	dstVPCIDs := []string{}
+
+	for _, sg := range sgList {
+		key := sgKey{
+			VpcID: awssync.destinationVPC(account, aws.StringValue(sg.VpcId)),
+			Name:  aws.StringValue(sg.GroupName),
+		}
+		sgIDMameMap[aws.StringValue(sg.GroupId)] = key
+		sourceOwnerID = aws.StringValue(sg.OwnerId)
+
+		if i, ok := index[key]; ok {
+			shared := &newSGList[i]
+			if key.Name != sgDefaultName {
+				exitErrorf("Security group %q of %s and %s map to the same destination VPC %s, set SecurityGroup VPCMap or Rename.",
+					key.Name, aws.StringValue(shared.GroupId), aws.StringValue(sg.GroupId), key.VpcID)
+			}
+
+			log.Printf("Default Security Group %s And %s Share The Default Group Of Destination VPC %s, Rules Merged.",
+				aws.StringValue(shared.GroupId), aws.StringValue(sg.GroupId), key.VpcID)
+			shared.IpPermissions = append(append([]ec2.IpPermission{}, shared.IpPermissions...), sg.IpPermissions...)
+			shared.IpPermissionsEgress = append(append([]ec2.IpPermission{}, shared.IpPermissionsEgress...), sg.IpPermissionsEgress...)
+			continue
+		}
+		index[key] = len(newSGList)
+		newSGList = append(newSGList, sg)
+
+		if !containsString(dstVPCIDs, key.VpcID) {
+			dstVPCIDs = append(dstVPCIDs, key.VpcID)
+		}
+	}
+
+	return newSGList, dstVPCIDs
+}
+
+// getDefaultSGID default security group of VPC, can not be created.
+func getDefaultSGID(account *awsAuth, vpcID string) *string {
+	return getSGIDByName(account, vpcID, sgDefaultName)
+}
+
+// getSGIDByName security group of name in VPC.
+func getSGIDByName(account *awsAuth, vpcID string, name string) *string {
+	svc := newSVC(account)
+
+	var result *ec2.DescribeSecurityGroupsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{
+			Filters: []ec2.Filter{
+				{
+					Name:   aws.String("group-name"),
+					Values: []string{name},
+				},
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []string{vpcID},
+				},
+			},
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		exitErrorf("Unable to get security group %s of VPC %s, %v", name, vpcID, err)
+	}
+
+	if len(result.SecurityGroups) == 0 {
+		exitErrorf("Unable to find security group %s of VPC %s.", name, vpcID)
+	}
+
+	return result.SecurityGroups[0].GroupId
+}
+
 // PerfixList ...
 type PerfixList struct {
 	OldPerfixListID   *string
@@ -312,7 +392,7 @@ func (awssync *AWSSync) GetPerfixLists(account *awsAuth) {
 	svc := newSVC(account)
 
 	for _, sg := range awssync.sourceSGLists {
-		for _, ipp := range sg.IpPermissions {
+		for _, ipp := range append(append([]ec2.IpPermission{}, sg.IpPermissions...), sg.IpPermissionsEgress...) {
 			for _, plids := range ipp.PrefixListIds {
 				if _, ok := awssync.perfixListMap[*plids.PrefixListId]; ok {
 					continue
@@ -320,25 +400,34 @@ func (awssync *AWSSync) GetPerfixLists(account *awsAuth) {
 
 				p := new(PerfixList)
 				p.OldPerfixListID = plids.PrefixListId
-				req := svc.GetManagedPrefixListEntriesRequest(&ec2.GetManagedPrefixListEntriesInput{
-					PrefixListId: p.OldPerfixListID,
+				var result *ec2.GetManagedPrefixListEntriesResponse
+				err := awsCall(serviceEC2, "Get PerfixList Entries", *p.OldPerfixListID, nil, func(ctx context.Context) (err error) {
+					result, err = svc.GetManagedPrefixListEntriesRequest(&ec2.GetManagedPrefixListEntriesInput{
+						PrefixListId: p.OldPerfixListID,
+					}).Send(ctx)
+					return err
 				})
-				result, err := req.Send(context.Background())
 				if err != nil {
-					log.Println("Get PerfixList Error", err)
+					exitErrorf("%v", err)
 				}
 
-				p.PrefixListEntry = result.Entries
-
-				desReq := svc.DescribeManagedPrefixListsRequest(&ec2.DescribeManagedPrefixListsInput{
-					PrefixListIds: []string{*p.OldPerfixListID},
+				var perfixListInfo *ec2.DescribeManagedPrefixListsResponse
+				err = awsCall(serviceEC2, "Describe PerfixList", *p.OldPerfixListID, nil, func(ctx context.Context) (err error) {
+					perfixListInfo, err = svc.DescribeManagedPrefixListsRequest(&ec2.DescribeManagedPrefixListsInput{
+						PrefixListIds: []string{*p.OldPerfixListID},
+					}).Send(ctx)
+					return err
 				})
-				perfixListInfo, err := desReq.Send(context.Background())
 				if err != nil {
-					log.Println("Describe Perfix Error", err)
+					exitErrorf("%v", err)
+				}
+				if len(perfixListInfo.PrefixLists) == 0 {
+					exitErrorf("Unable to find PerfixList %s.", *p.OldPerfixListID)
 				}
 
 				p.ManagedPrefixList = perfixListInfo.PrefixLists[0]
+				p.PrefixListEntry = awssync.cidr.translatePrefixListEntries(
+					"prefix list "+aws.StringValue(p.ManagedPrefixList.PrefixListName), result.Entries)
 
 				awssync.perfixListMap[*p.OldPerfixListID] = p
 
@@ -350,24 +439,42 @@ func (awssync *AWSSync) GetPerfixLists(account *awsAuth) {
 }
 
 func (awssync *AWSSync) createPerfixList(svc *ec2.Client) {
-	for i, v := range awssync.perfixListMap {
-		PerfixListAddr := convertAddPerfixList(v.PrefixListEntry)
-		tags := []ec2.Tag{}
-
-		req := svc.CreateManagedPrefixListRequest(&ec2.CreateManagedPrefixListInput{
-			AddressFamily:     v.ManagedPrefixList.AddressFamily,
-			Entries:           PerfixListAddr,
-			PrefixListName:    v.ManagedPrefixList.PrefixListName,
-			MaxEntries:        v.ManagedPrefixList.MaxEntries,
-			TagSpecifications: awssync.setSGTags(tags, "prefix-list"),
-		})
-		result, err := req.Send(context.Background())
-		if err != nil {
-			log.Println("Create PerfixList Error", err)
-		}
+	jobs := []func() error{}
+
+	for _, v := range awssync.perfixListMap {
+		v := v
+		jobs = append(jobs, func() error {
+			PerfixListAddr := convertAddPerfixList(v.PrefixListEntry)
+			tags := []ec2.Tag{}
+			token := clientToken(aws.StringValue(v.OldPerfixListID))
+
+			var result *ec2.CreateManagedPrefixListResponse
+			err := awsCall(serviceEC2, "Create PerfixList", aws.StringValue(v.ManagedPrefixList.PrefixListName), nil, func(ctx context.Context) (err error) {
+				result, err = svc.CreateManagedPrefixListRequest(&ec2.CreateManagedPrefixListInput{
+					DryRun:            aws.Bool(dryRun),
+					ClientToken:       token,
+					AddressFamily:     v.ManagedPrefixList.AddressFamily,
+					Entries:           PerfixListAddr,
+					PrefixListName:    v.ManagedPrefixList.PrefixListName,
+					MaxEntries:        v.ManagedPrefixList.MaxEntries,
+					TagSpecifications: awssync.setSGTags(tags, "prefix-list"),
+				}).Send(ctx)
+				return err
+			})
+			if err != nil {
+				if dryRunOK(err, "Create PerfixList %s", aws.StringValue(v.ManagedPrefixList.PrefixListName)) {
+					v.newPerfixListID = dryRunID(aws.StringValue(v.OldPerfixListID))
+					return nil
+				}
+				return err
+			}
 
-		awssync.perfixListMap[i].newPerfixListID = result.PrefixList.PrefixListId
+			v.newPerfixListID = result.PrefixList.PrefixListId
+			return nil
+		})
 	}
+
+	exitOnErrors(runParallel(jobs))
 }
 
 func convertAddPerfixList(plist []ec2.PrefixListEntry) []ec2.AddPrefixListEntry {
@@ -442,44 +549,49 @@ func removeSGDefaultValue(sgList []ec2.SecurityGroup) []ec2.SecurityGroup {
 	return sgList
 }
 
-func replaceGroupID(ippMap BuildSGMapType) BuildSGMapType {
+// replaceGroupID source group id to destination group id, user id group pairs remapped, unmappable
+// references reported and skipped, permission skipped if nothing else left.
+func (awssync *AWSSync) replaceGroupID(ippMap BuildSGMapType, ruleType string) BuildSGMapType {
 	newBuildSG := make(BuildSGMapType)
 
 	for gid, data := range ippMap {
-		gName, ok := sgIDMameMap[aws.StringValue(gid)]
+		key, ok := sgIDMameMap[aws.StringValue(gid)]
 		if !ok {
 			log.Println("Not Found Old Security Group ID In Map, ID:", *gid)
-			break
+			continue
 		}
 
-		newGID, ok := newSGNameIDMap[gName]
+		newGID, ok := newSGNameIDMap[key]
 		if !ok {
-			log.Println("Not Found New Security Group Name In Map, Name:", gName)
-			break
+			log.Printf("Not Found New Security Group Name In Map, Name: %s, VPC: %s", key.Name, key.VpcID)
+			continue
 		}
 
-		newBuildSG[aws.String(newGID)] = data
-	}
-
-	for _, ipp := range newBuildSG {
-		for ii, ips := range ipp {
+		ippList := []ec2.IpPermission{}
+		for _, ipp := range data {
 			ugps := []ec2.UserIdGroupPair{}
-			for _, ugp := range ips.UserIdGroupPairs {
-				gName, ok := sgIDMameMap[aws.StringValue(ugp.GroupId)]
-				if !ok {
-					log.Println("Not Found Old Security Group ID In Map, From UserIdGroupPairs, ID:", *ugp.GroupId)
-					break
+			for _, ugp := range ipp.UserIdGroupPairs {
+				newUGP, reason := awssync.mapUGP(ugp)
+				if len(reason) > 0 {
+					awssync.reportReference(aws.StringValue(gid), ruleType, ugp, reason)
+					continue
 				}
+				ugps = append(ugps, newUGP)
+			}
 
-				newID, ok := newSGNameIDMap[gName]
-				if !ok {
-					log.Println("Not Found New Security Group Name In Map, From UserIdGroupPairs, Name:", gName)
-					break
+			ipp.UserIdGroupPairs = ugps
+			if len(ugps) == 0 {
+				// ranges of the same permission kept.
+				if len(ipp.IpRanges)+len(ipp.Ipv6Ranges)+len(ipp.PrefixListIds) == 0 {
+					continue
 				}
-				ugp.GroupId = aws.String(newID)
-				ugps = append(ugps, ugp)
+				ipp.UserIdGroupPairs = nil
 			}
-			ipp[ii].UserIdGroupPairs = ugps
+			ippList = append(ippList, ipp)
+		}
+
+		if len(ippList) > 0 {
+			newBuildSG[aws.String(newGID)] = ippList
 		}
 	}
 
@@ -488,18 +600,24 @@ func replaceGroupID(ippMap BuildSGMapType) BuildSGMapType {
 
 func (awssync *AWSSync) replacePerfixListID(sgList []ec2.SecurityGroup) []ec2.SecurityGroup {
 	for _, sg := range sgList {
-		for ii, ipp := range sg.IpPermissions {
-			if len(ipp.PrefixListIds) > 0 {
-				prefixListIDs := []ec2.PrefixListId{}
-				for _, plist := range ipp.PrefixListIds {
-					plist.PrefixListId = awssync.perfixListMap[*plist.PrefixListId].newPerfixListID
-					prefixListIDs = append(prefixListIDs, plist)
-				}
-				sg.IpPermissions[ii].PrefixListIds = prefixListIDs
+		awssync.replaceIppPerfixListID(sg.IpPermissions)
+		awssync.replaceIppPerfixListID(sg.IpPermissionsEgress)
+	}
+	return sgList
+}
+
+// replaceIppPerfixListID source prefix list id to destination, ingress or egress.
+func (awssync *AWSSync) replaceIppPerfixListID(ippList []ec2.IpPermission) {
+	for ii, ipp := range ippList {
+		if len(ipp.PrefixListIds) > 0 {
+			prefixListIDs := []ec2.PrefixListId{}
+			for _, plist := range ipp.PrefixListIds {
+				plist.PrefixListId = awssync.perfixListMap[*plist.PrefixListId].newPerfixListID
+				prefixListIDs = append(prefixListIDs, plist)
 			}
+			ippList[ii].PrefixListIds = prefixListIDs
 		}
 	}
-	return sgList
 }
 
 func (awssync *AWSSync) setSGTags(tags []ec2.Tag, resourceType ec2.ResourceType) []ec2.TagSpecification {
@@ -528,37 +646,28 @@ func (awssync *AWSSync) setSGTags(tags []ec2.Tag, resourceType ec2.ResourceType)
 	return []ec2.TagSpecification{*tagList}
 }
 
-func appendSGUGPRule(svc *ec2.Client) {
-	if len(ipps) > 0 {
-		newUgpMap := replaceGroupID(ipps)
+// appendSGUGPRule after all groups created, rules of groups authorized in parallel.
+func (awssync *AWSSync) appendSGUGPRule(svc *ec2.Client) {
+	jobs := []func() error{}
 
-		for gid, ipps := range newUgpMap {
-			req := svc.AuthorizeSecurityGroupIngressRequest(&ec2.AuthorizeSecurityGroupIngressInput{
-				GroupId:       gid,
-				IpPermissions: ipps,
-			})
-			_, err := req.Send(context.Background())
-			if err != nil {
-				exitErrorf("Unable to append set security group %q ingress, %v", *gid, err)
-			}
+	for ruleType, ippMap := range map[string]BuildSGMapType{"ingress": ipps, "egress": ippes} {
+		if len(ippMap) == 0 {
+			continue
 		}
 
-	}
-
-	if len(ippes) > 0 {
-		newUgpMap := replaceGroupID(ippes)
-
-		for gid, ipps := range newUgpMap {
-			req := svc.AuthorizeSecurityGroupEgressRequest(&ec2.AuthorizeSecurityGroupEgressInput{
-				GroupId:       gid,
-				IpPermissions: ipps,
+		ruleType := ruleType
+		for gid, ippList := range awssync.replaceGroupID(ippMap, ruleType) {
+			gid, ippList := gid, ippList
+			jobs = append(jobs, func() error {
+				if err := authorizeSGRules(svc, gid, ruleType, ippList); err != nil {
+					return fmt.Errorf("Unable to append set security group %q %s, %v", *gid, ruleType, err)
+				}
+				return nil
 			})
-			_, err := req.Send(context.Background())
-			if err != nil {
-				exitErrorf("Unable to append set security group %q Egress, %v", *gid, err)
-			}
 		}
 	}
+
+	exitOnErrors(runParallel(jobs))
 }
 
 func findIPPermissionsBySGID(sgList []ec2.SecurityGroup, groupID *string) (ipps []ec2.IpPermission, ippes []ec2.IpPermission) {
@@ -570,190 +679,335 @@ func findIPPermissionsBySGID(sgList []ec2.SecurityGroup, groupID *string) (ipps
 	return nil, nil
 }
 
+// authorizeSGRules ruleType ingress / egress, honor dry run, existing permissions skipped.
+func authorizeSGRules(svc *ec2.Client, groupID *string, ruleType string, ippList []ec2.IpPermission) error {
+	action := fmt.Sprintf("Authorize Security Group %s %s, %d Permissions", aws.StringValue(groupID), ruleType, len(ippList))
+	if hasDryRunID(groupID, ippList) {
+		dryRunSimulate("%s", action)
+		return nil
+	}
+
+	send := func(ippList []ec2.IpPermission, idempotent ...string) error {
+		if ruleType == "egress" {
+			return awsCall(serviceEC2, "Authorize Security Group Egress", aws.StringValue(groupID), idempotent, func(ctx context.Context) error {
+				_, err := svc.AuthorizeSecurityGroupEgressRequest(&ec2.AuthorizeSecurityGroupEgressInput{
+					DryRun:        aws.Bool(dryRun),
+					GroupId:       groupID,
+					IpPermissions: ippList,
+				}).Send(ctx)
+				return err
+			})
+		}
+
+		return awsCall(serviceEC2, "Authorize Security Group Ingress", aws.StringValue(groupID), idempotent, func(ctx context.Context) error {
+			_, err := svc.AuthorizeSecurityGroupIngressRequest(&ec2.AuthorizeSecurityGroupIngressInput{
+				DryRun:        aws.Bool(dryRun),
+				GroupId:       groupID,
+				IpPermissions: ippList,
+			}).Send(ctx)
+			return err
+		})
+	}
+
+	err := sendSGRulesEach(send, ippList, errCodePermissionDuplicate)
+	if err != nil && dryRunOK(err, "%s", action) {
+		return nil
+	}
+	return err
+}
+
+// revokeSGRules ruleType ingress / egress, honor dry run, missing permissions skipped.
+func revokeSGRules(svc *ec2.Client, groupID *string, ruleType string, ippList []ec2.IpPermission) error {
+	action := fmt.Sprintf("Revoke Security Group %s %s, %d Permissions", aws.StringValue(groupID), ruleType, len(ippList))
+	if hasDryRunID(groupID, ippList) {
+		dryRunSimulate("%s", action)
+		return nil
+	}
+
+	send := func(ippList []ec2.IpPermission, idempotent ...string) error {
+		if ruleType == "egress" {
+			return awsCall(serviceEC2, "Revoke Security Group Egress", aws.StringValue(groupID), idempotent, func(ctx context.Context) error {
+				_, err := svc.RevokeSecurityGroupEgressRequest(&ec2.RevokeSecurityGroupEgressInput{
+					DryRun:        aws.Bool(dryRun),
+					GroupId:       groupID,
+					IpPermissions: ippList,
+				}).Send(ctx)
+				return err
+			})
+		}
+
+		return awsCall(serviceEC2, "Revoke Security Group Ingress", aws.StringValue(groupID), idempotent, func(ctx context.Context) error {
+			_, err := svc.RevokeSecurityGroupIngressRequest(&ec2.RevokeSecurityGroupIngressInput{
+				DryRun:        aws.Bool(dryRun),
+				GroupId:       groupID,
+				IpPermissions: ippList,
+			}).Send(ctx)
+			return err
+		})
+	}
+
+	err := sendSGRulesEach(send, ippList, errCodePermissionNotFound)
+	if err != nil && dryRunOK(err, "%s", action) {
+		return nil
+	}
+	return err
+}
+
+// sendSGRulesEach one permission of code fails the whole request, send permissions one by one
+// with code as success.
+func sendSGRulesEach(send func([]ec2.IpPermission, ...string) error, ippList []ec2.IpPermission, code string) error {
+	err := send(ippList)
+	if errorCode(err) != code {
+		return err
+	}
+
+	log.Printf("%v, Send Permissions One By One.", err)
+
+	for _, ipp := range splitIPPermissions(ippList) {
+		if err := send([]ec2.IpPermission{ipp}, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitIPPermissions one source (cidr / prefix list / group) per permission.
+func splitIPPermissions(ippList []ec2.IpPermission) []ec2.IpPermission {
+	newIppList := []ec2.IpPermission{}
+
+	for _, ipp := range ippList {
+		base := ec2.IpPermission{
+			FromPort:   ipp.FromPort,
+			ToPort:     ipp.ToPort,
+			IpProtocol: ipp.IpProtocol,
+		}
+
+		for _, r := range ipp.IpRanges {
+			p := base
+			p.IpRanges = []ec2.IpRange{r}
+			newIppList = append(newIppList, p)
+		}
+		for _, r := range ipp.Ipv6Ranges {
+			p := base
+			p.Ipv6Ranges = []ec2.Ipv6Range{r}
+			newIppList = append(newIppList, p)
+		}
+		for _, pl := range ipp.PrefixListIds {
+			p := base
+			p.PrefixListIds = []ec2.PrefixListId{pl}
+			newIppList = append(newIppList, p)
+		}
+		for _, ugp := range ipp.UserIdGroupPairs {
+			p := base
+			p.UserIdGroupPairs = []ec2.UserIdGroupPair{ugp}
+			newIppList = append(newIppList, p)
+		}
+	}
+
+	return newIppList
+}
+
 // UpdateSGList ...
 func (awssync *AWSSync) UpdateSGList(account *awsAuth, srcSID ...string) {
 	svc := newSVC(account)
 
-	dstSGLIst := GetSGList(account)
-
 	awssync.createPerfixList(svc)
 
 	newSrcSGList := removeSGDefaultValue(awssync.sourceSGLists)
 	newSrcSGList = addNewUGPMap(newSrcSGList)
 
-	for _, sg := range newSrcSGList {
-		sgIDMameMap[*sg.GroupId] = *sg.GroupName
-	}
+	newSrcSGList, dstVPCIDs := awssync.mapSourceSGList(account, newSrcSGList)
+	dstSGLIst := GetSGListByVPC(account, dstVPCIDs...)
 
 	for _, sg := range dstSGLIst {
-		newSGNameIDMap[*sg.GroupName] = *sg.GroupId
+		newSGNameIDMap[sgKey{VpcID: aws.StringValue(sg.VpcId), Name: aws.StringValue(sg.GroupName)}] = *sg.GroupId
 	}
 
 	newSrcSGList = awssync.replacePerfixListID(newSrcSGList)
 
+	jobs := []func() error{}
+
 	for _, sg := range newSrcSGList {
-		RevokGroupID := newSGNameIDMap[*sg.GroupName]
+		sg := sg
+		key := sgIDMameMap[*sg.GroupId]
+		RevokGroupID, ok := newSGNameIDMap[key]
+		if !ok {
+			log.Printf("Not Found Destination Security Group %s In VPC %s, Ignore Update.", key.Name, key.VpcID)
+			continue
+		}
 
-		ippsByIP, ippesByIP := findIPPermissionsBySGID(dstSGLIst, &RevokGroupID)
+		jobs = append(jobs, func() error {
+			ippsByIP, ippesByIP := findIPPermissionsBySGID(dstSGLIst, &RevokGroupID)
 
-		if len(ippsByIP) > 0 {
-			req := svc.RevokeSecurityGroupIngressRequest(&ec2.RevokeSecurityGroupIngressInput{
-				GroupId:       aws.String(RevokGroupID),
-				IpPermissions: ippsByIP,
-			})
-			_, err := req.Send(context.Background())
-			if err != nil {
-				exitErrorf("Unable to revoke security group %q Ingress, %v", &RevokGroupID, err)
-			}
-			if len(sg.IpPermissions) > 0 {
-				reqAuthor := svc.AuthorizeSecurityGroupIngressRequest(&ec2.AuthorizeSecurityGroupIngressInput{
-					GroupId:       aws.String(RevokGroupID),
-					IpPermissions: sg.IpPermissions,
-				})
-				_, err = reqAuthor.Send(context.Background())
+			if len(ippsByIP) > 0 {
+				err := revokeSGRules(svc, aws.String(RevokGroupID), "ingress", ippsByIP)
 				if err != nil {
-					exitErrorf("Unable to set security group %q ingress, %v", *sg.GroupName, err)
+					return fmt.Errorf("Unable to revoke security group %q Ingress, %v", RevokGroupID, err)
 				}
+				if len(sg.IpPermissions) > 0 {
+					err = authorizeSGRules(svc, aws.String(RevokGroupID), "ingress", sg.IpPermissions)
+					if err != nil {
+						return fmt.Errorf("Unable to set security group %q ingress, %v", *sg.GroupName, err)
+					}
+				}
+			} else {
+				log.Printf("Unable to revoke security group %q, not found ingress rules from destination", RevokGroupID)
 			}
-		} else {
-			log.Printf("Unable to revoke security group %q, not found ingress rules from destination", RevokGroupID)
-		}
 
-		if len(ippesByIP) > 0 {
-			req := svc.RevokeSecurityGroupEgressRequest(&ec2.RevokeSecurityGroupEgressInput{
-				GroupId:       aws.String(RevokGroupID),
-				IpPermissions: ippesByIP,
-			})
-			_, err := req.Send(context.Background())
-			if err != nil {
-				exitErrorf("Unable to revoke security group %q Egress, %v", &RevokGroupID, err)
-			}
-			if len(sg.IpPermissionsEgress) > 0 {
-				reqAuthor := svc.AuthorizeSecurityGroupEgressRequest(&ec2.AuthorizeSecurityGroupEgressInput{
-					GroupId:       aws.String(RevokGroupID),
-					IpPermissions: sg.IpPermissionsEgress,
-				})
-				_, err = reqAuthor.Send(context.Background())
+			if len(ippesByIP) > 0 {
+				err := revokeSGRules(svc, aws.String(RevokGroupID), "egress", ippesByIP)
 				if err != nil {
-					exitErrorf("Unable to set security group %q Egress, %v", *sg.GroupName, err)
+					return fmt.Errorf("Unable to revoke security group %q Egress, %v", RevokGroupID, err)
 				}
+				if len(sg.IpPermissionsEgress) > 0 {
+					err = authorizeSGRules(svc, aws.String(RevokGroupID), "egress", sg.IpPermissionsEgress)
+					if err != nil {
+						return fmt.Errorf("Unable to set security group %q Egress, %v", *sg.GroupName, err)
+					}
+				}
+			} else {
+				log.Printf("Unable to revoke security group %q, not found egress rules from destination", RevokGroupID)
 			}
-		} else {
-			log.Printf("Unable to revoke security group %q, not found egress rules from destination", RevokGroupID)
-		}
 
-		log.Printf("Successfully update security group %q", RevokGroupID)
+			log.Printf("Successfully update security group %q", RevokGroupID)
+			return nil
+		})
 	}
-	appendSGUGPRule(svc)
+	exitOnErrors(runParallel(jobs))
+
+	awssync.appendSGUGPRule(svc)
+	awssync.writeReferenceReport()
 
 	log.Print("Update Done.")
 }
 
 // CreateAndSyncSGList ...
-func (awssync *AWSSync) CreateAndSyncSGList(account *awsAuth, dryRun *bool) {
+func (awssync *AWSSync) CreateAndSyncSGList(account *awsAuth) {
 	svc := newSVC(account)
 
-	defaultSGID := GetFilterSGListByNames(account, sgDefaultName)[0]
-
 	awssync.createPerfixList(svc)
 
 	newSrcSGList := removeSGDefaultValue(awssync.sourceSGLists)
 	newSrcSGList = addNewUGPMap(newSrcSGList)
 	newSrcSGList = awssync.replacePerfixListID(newSrcSGList)
 
+	newSrcSGList, _ = awssync.mapSourceSGList(account, newSrcSGList)
+
+	// group shells and rules without group references, references appended after all created.
+	jobs := []func() error{}
 	for _, sg := range newSrcSGList {
-		var resGroupID *string
+		sg := sg
+		jobs = append(jobs, func() error {
+			return awssync.createAndSyncSG(svc, account, sg)
+		})
+	}
+	exitOnErrors(runParallel(jobs))
+
+	awssync.appendSGUGPRule(svc)
+	awssync.writeReferenceReport()
+
+	log.Print("Create Done.")
+}
 
-		sgIDMameMap[*sg.GroupId] = *sg.GroupName
+func (awssync *AWSSync) createAndSyncSG(svc *ec2.Client, account *awsAuth, sg ec2.SecurityGroup) error {
+	var resGroupID *string
 
-		req := svc.CreateSecurityGroupRequest(&ec2.CreateSecurityGroupInput{
-			DryRun:            dryRun,
-			GroupName:         sg.GroupName,
-			Description:       sg.Description,
-			VpcId:             aws.String(account.VIPCID),
-			TagSpecifications: awssync.setSGTags(sg.Tags, ec2.ResourceTypeSecurityGroup),
+	key := sgIDMameMap[*sg.GroupId]
+
+	var (
+		createRes *ec2.CreateSecurityGroupResponse
+		err       error
+	)
+	if isDryRunID(aws.String(key.VpcID)) {
+		// VPC not created in dry run.
+		err = awserr.New(dryRunOperationCode, "VPC not created in dry run", nil)
+	} else {
+		err = awsCall(serviceEC2, "Create Security Group", key.Name+" With VPC "+key.VpcID, nil, func(ctx context.Context) (err error) {
+			createRes, err = svc.CreateSecurityGroupRequest(&ec2.CreateSecurityGroupInput{
+				DryRun:            aws.Bool(dryRun),
+				GroupName:         sg.GroupName,
+				Description:       sg.Description,
+				VpcId:             aws.String(key.VpcID),
+				TagSpecifications: awssync.setSGTags(sg.Tags, ec2.ResourceTypeSecurityGroup),
+			}).Send(ctx)
+			return err
 		})
-		createRes, err := req.Send(context.Background())
-		if err != nil {
-			if aerr, ok := err.(awserr.Error); ok {
-				switch aerr.Code() {
-				case "InvalidVpcID.NotFound":
-					exitErrorf("Unable to find VPC with ID %q.", account.VIPCID)
-				case "InvalidGroup.Duplicate":
-					exitErrorf("Security group %q already exists.", *sg.GroupName)
-				case "DryRunOperation":
-					exitErrorf("DryRunOperation to create security group %q, %v", *sg.GroupName, err)
-				case "InvalidParameterValue":
-					if aws.StringValue(sg.GroupName) == sgDefaultName {
-						log.Print("Found default group, switch id.")
-						resGroupID = defaultSGID.GroupId
-						break
-					}
-					exitErrorf("InvalidParameterValue to create security group %q, %v", *sg.GroupName, err)
-				default:
-					exitErrorf("Unable to create security group %q, %v", *sg.GroupName, err)
-				}
+	}
+	if err != nil {
+		switch errorCode(err) {
+		case "InvalidVpcID.NotFound":
+			return fmt.Errorf("Unable to find VPC with ID %q.", key.VpcID)
+		case errCodeGroupDuplicate:
+			// created by previous run, rules authorized idempotently.
+			log.Printf("Security group %q already exists in VPC %s, reuse it.", *sg.GroupName, key.VpcID)
+			resGroupID = getSGIDByName(account, key.VpcID, aws.StringValue(sg.GroupName))
+		case dryRunOperationCode:
+			dryRunRecord("Create Security Group %s With VPC %s", aws.StringValue(sg.GroupName), key.VpcID)
+			resGroupID = dryRunID(aws.StringValue(sg.GroupName))
+		case "InvalidParameterValue":
+			if aws.StringValue(sg.GroupName) == sgDefaultName {
+				log.Print("Found default group, switch id.")
+				resGroupID = getDefaultSGID(account, key.VpcID)
+				break
 			}
-		} else {
-			resGroupID = createRes.GroupId
+			return fmt.Errorf("InvalidParameterValue to create security group %q, %v", *sg.GroupName, err)
+		default:
+			return err
 		}
+	} else {
+		resGroupID = createRes.GroupId
+	}
 
-		// clean create Security Group default value
-		reqRevoke := svc.RevokeSecurityGroupEgressRequest(&ec2.RevokeSecurityGroupEgressInput{
-			GroupId: resGroupID,
-			IpPermissions: []ec2.IpPermission{
+	// clean create Security Group default value
+	err = revokeSGRules(svc, resGroupID, "egress", []ec2.IpPermission{
+		{
+			FromPort:   aws.Int64(-1),
+			IpProtocol: aws.String("-1"),
+			IpRanges: []ec2.IpRange{
 				{
-					FromPort:   aws.Int64(-1),
-					IpProtocol: aws.String("-1"),
-					IpRanges: []ec2.IpRange{
-						{
-							CidrIp: aws.String("0.0.0.0/0"),
-						},
-					},
+					CidrIp: aws.String("0.0.0.0/0"),
 				},
 			},
-		})
-		_, err = reqRevoke.Send(context.Background())
-		if err != nil {
-			exitErrorf("Revoke Default Security Group Rule Egress Error", err)
-		}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Revoke Default Security Group Rule Egress Error, %v", err)
+	}
 
-		// all new security group id
-		newSGNameIDMap[*sg.GroupName] = *resGroupID
+	// all new security group id
+	sgMapLock.Lock()
+	newSGNameIDMap[key] = *resGroupID
+	sgMapLock.Unlock()
 
+	if !isDryRunID(resGroupID) {
 		log.Printf("Created security group %s(%s) with VPC %s.\n",
-			aws.StringValue(sg.GroupName), aws.StringValue(resGroupID), account.VIPCID)
+			aws.StringValue(sg.GroupName), aws.StringValue(resGroupID), key.VpcID)
+	}
 
-		if len(sg.IpPermissions) > 0 {
-			reqAuth := svc.AuthorizeSecurityGroupIngressRequest(&ec2.AuthorizeSecurityGroupIngressInput{
-				GroupId:       resGroupID,
-				IpPermissions: sg.IpPermissions,
-			})
-			_, err := reqAuth.Send(context.Background())
-			if err != nil {
-				exitErrorf("Unable to set security group %q ingress, %v", *sg.GroupName, err)
-			}
+	if len(sg.IpPermissions) > 0 {
+		err := authorizeSGRules(svc, resGroupID, "ingress", sg.IpPermissions)
+		if err != nil {
+			return fmt.Errorf("Unable to set security group %q ingress, %v", *sg.GroupName, err)
 		}
+	}
 
-		if len(sg.IpPermissionsEgress) > 0 {
-			req := svc.AuthorizeSecurityGroupEgressRequest(&ec2.AuthorizeSecurityGroupEgressInput{
-				GroupId:       resGroupID,
-				IpPermissions: sg.IpPermissionsEgress,
-			})
-			_, err := req.Send(context.Background())
-			if err != nil {
-				exitErrorf("Unable to set security group %q Egress, %v", *sg.GroupName, err)
-			}
+	if len(sg.IpPermissionsEgress) > 0 {
+		err := authorizeSGRules(svc, resGroupID, "egress", sg.IpPermissionsEgress)
+		if err != nil {
+			return fmt.Errorf("Unable to set security group %q Egress, %v", *sg.GroupName, err)
 		}
 	}
 
-	appendSGUGPRule(svc)
-
-	log.Print("Create Done.")
+	return nil
 }
 
-// CleanSecurityGroupRule ...
-func CleanSecurityGroupRule(account *awsAuth) {
+// CleanSecurityGroupRule revoke all rules, confirmAccount must be the account id, even if --yes.
+func CleanSecurityGroupRule(account *awsAuth, confirmAccount string) {
+	accountID := getAccountID(account)
+	if confirmAccount != accountID {
+		log.Fatalf("Clean Security Group Rule Requires --confirm-account %s (Got %q).", accountID, confirmAccount)
+	}
+
 	c := askForConfirmation("Doooooooooooooooooooooooooooooooooooooon't, Are You Sure?")
 	if !c {
 		fmt.Println("Bye...")
@@ -768,8 +1022,11 @@ func CleanSecurityGroupRule(account *awsAuth) {
 
 	svc := newSVC(account)
 
-	req := svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{})
-	result, err := req.Send(context.Background())
+	var result *ec2.DescribeSecurityGroupsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSecurityGroupsRequest(&ec2.DescribeSecurityGroupsInput{}).Send(ctx)
+		return err
+	})
 	if err != nil {
 		exitErrorf("Get All Security Groups Failed", err)
 	}
@@ -777,20 +1034,12 @@ func CleanSecurityGroupRule(account *awsAuth) {
 	log.Print("Do It.")
 
 	for _, v := range result.SecurityGroups {
-		req := svc.RevokeSecurityGroupIngressRequest(&ec2.RevokeSecurityGroupIngressInput{
-			GroupId:       v.GroupId,
-			IpPermissions: v.IpPermissions,
-		})
-		_, err := req.Send(context.Background())
+		err := revokeSGRules(svc, v.GroupId, "ingress", v.IpPermissions)
 		if err != nil {
 			log.Println("Revoke Security Group Ingress Error", err)
 		}
 
-		reqE := svc.RevokeSecurityGroupEgressRequest(&ec2.RevokeSecurityGroupEgressInput{
-			GroupId:       v.GroupId,
-			IpPermissions: v.IpPermissionsEgress,
-		})
-		_, err = reqE.Send(context.Background())
+		err = revokeSGRules(svc, v.GroupId, "egress", v.IpPermissionsEgress)
 		if err != nil {
 			log.Println("Revoke Security Group Egress Error", err)
 		}
@@ -800,56 +1049,79 @@ func CleanSecurityGroupRule(account *awsAuth) {
 
 func exitErrorf(msg string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	DryRunSummary()
 	os.Exit(1)
 }
 
-// DiffSecurityGroup ...
-func DiffSecurityGroup(awsAccount *AWSAccount) {
-	sourceSGList := GetSGList(&awsAccount.Source)
-	destinationSGList := GetSGList(&awsAccount.Destination)
-
-	if !reflect.DeepEqual(sourceSGList, destinationSGList) {
-		for _, ssg := range sourceSGList {
-			for _, dsg := range destinationSGList {
-				if aws.StringValue(ssg.GroupName) == aws.StringValue(dsg.GroupName) {
-					// fmt.Println(i, ii, aws.StringValue(ssg.GroupName), aws.StringValue(dsg.GroupName))
-					if !reflect.DeepEqual(ssg.IpPermissions, dsg.IpPermissions) {
-						log.Printf("Security Group Name: %s, Inbound Not Match", aws.StringValue(ssg.GroupName))
-					}
-					if !reflect.DeepEqual(ssg.IpPermissionsEgress, dsg.IpPermissionsEgress) {
-						log.Printf("Security Group Name: %s, Outbound  Not Match", aws.StringValue(ssg.GroupName))
-					}
-				}
-			}
+// sgIDMappings source group id: destination group id, after sync.
+func sgIDMappings() map[string]string {
+	mappings := make(map[string]string)
+	for srcID, key := range sgIDMameMap {
+		if dstID, ok := newSGNameIDMap[key]; ok {
+			mappings[srcID] = dstID
 		}
-	} else {
-		log.Print("Security Group All Match.")
 	}
+	return mappings
 }
 
-func convertTf(sgList []ec2.SecurityGroup, tags *[]Tag) *bytes.Buffer {
-	funcMap := template.FuncMap{
-		"now": time.Now,
-		"customTags": func() *[]Tag {
-			return tags
-		},
+// DiffSecurityGroup compare source and destination security group, rule level.
+func DiffSecurityGroup(awsAccount *AWSAccount) {
+	from := newSnapshot(&awsAccount.Source, getSourceSGList(awsAccount))
+	to := newSnapshot(&awsAccount.Destination, GetSGList(&awsAccount.Destination))
+
+	newCIDRTranslator(&awsAccount.CIDR, &awsAccount.Source, &awsAccount.Destination, awsAccount.SecurityGroup.VPCIDs, awsAccount.SecurityGroup.VPCMap).translateSnapshot(from)
+
+	filterDiffSnapshots(from, to, &awsAccount.SecurityGroup, true)
+	diffSnapshots(from, to)
+}
+
+// DiffSnapshotFile compare snapshot file with another snapshot file, or live account (src, dst).
+func DiffSnapshotFile(from string, to string, live string, filter *SecurityGroupConfig) {
+	fromSnapshot := loadSnapshot(from)
+
+	var toSnapshot *Snapshot
+	switch live {
+	case "":
+		if len(to) == 0 {
+			log.Fatalln("Diff needs --to FILE or --live src|dst.")
+		}
+		toSnapshot = loadSnapshot(to)
+	case "src":
+		toSnapshot = newSnapshot(&yamlConfig.Setting.Source, getSourceSGList(&yamlConfig.Setting))
+	case "dst":
+		toSnapshot = newSnapshot(&yamlConfig.Setting.Destination, GetSGList(&yamlConfig.Setting.Destination))
+	default:
+		log.Fatalf("Unknown live account: %s", live)
 	}
 
-	tmpl, err := template.New("security_groups.tmpl").Funcs(funcMap).ParseFiles("template/security_groups.tmpl")
-	if err != nil {
-		log.Fatalf("parsing: %s", err)
+	if live == "dst" {
+		setting := &yamlConfig.Setting
+		newCIDRTranslator(&setting.CIDR, &setting.Source, &setting.Destination, setting.SecurityGroup.VPCIDs, setting.SecurityGroup.VPCMap).translateSnapshot(fromSnapshot)
+	}
+
+	filterDiffSnapshots(fromSnapshot, toSnapshot, filter, live != "src")
+	diffSnapshots(fromSnapshot, toSnapshot)
+}
+
+func convertTf(tf *TerraformExport, sgList []ec2.SecurityGroup) *bytes.Buffer {
+	sgList = tf.renameSGList(sgList)
+
+	for _, sg := range sgList {
+		tf.register(tfSecurityGroup, aws.StringValue(sg.GroupId), aws.StringValue(sg.GroupName))
 	}
+
+	tmpl := tf.parse()
+
 	buf := &bytes.Buffer{}
-	for i, sg := range sgList {
-		if i > 0 {
-			buf.WriteString("\n")
-		}
-		err := tmpl.Execute(buf, sg)
-		if err != nil {
-			log.Fatalf("execution: %s", err)
-		}
-		buf.WriteString("\n")
+	if tf.main {
+		tf.execute(tmpl, buf, tfMainTemplate, TemplateData{Resources: &ExportResources{SecurityGroups: sgList}})
+		return buf
+	}
+
+	for _, sg := range sgList {
+		tf.execute(tmpl, buf, "security_groups.tmpl", sg)
 	}
+	tf.executeSGRules(tmpl, buf, sgList)
 
 	return buf
 }
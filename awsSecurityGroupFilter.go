@@ -0,0 +1,302 @@
+package main
+
+import (
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+const sgNameRegexPrefix = "re:"
+
+// sgCandidate security group fields of filter, from ec2 or snapshot.
+type sgCandidate struct {
+	ID    string
+	Name  string
+	VpcID string
+	Tags  map[string]string
+	// referenced group id by user id group pairs, same account.
+	Refs []string
+}
+
+func (config *SecurityGroupConfig) empty() bool {
+	return config == nil || len(config.IncludeNames)+len(config.ExcludeNames)+len(config.Tags)+
+		len(config.VPCIDs)+len(config.IDs)+len(config.ExcludeIDs) == 0
+}
+
+// withoutIDs name and tag filters only, ids and vpc ids are source side.
+func (config *SecurityGroupConfig) withoutIDs() *SecurityGroupConfig {
+	if config == nil {
+		return nil
+	}
+	return &SecurityGroupConfig{
+		IncludeNames: config.IncludeNames,
+		ExcludeNames: config.ExcludeNames,
+		Tags:         config.Tags,
+	}
+}
+
+// destination name and tag filters, source VPCIDs mapped to destination by VPCMap, default destination VPCID.
+func (config *SecurityGroupConfig) destination(dst *awsAuth) *SecurityGroupConfig {
+	dstConfig := config.withoutIDs()
+	if dstConfig == nil {
+		return nil
+	}
+	for _, srcVPCID := range config.VPCIDs {
+		dstVPCID, ok := config.VPCMap[srcVPCID]
+		if !ok {
+			dstVPCID = dst.VIPCID
+		}
+		if len(dstVPCID) > 0 && !containsString(dstConfig.VPCIDs, dstVPCID) {
+			dstConfig.VPCIDs = append(dstConfig.VPCIDs, dstVPCID)
+		}
+	}
+	return dstConfig
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSGName glob, or regex with "re:" prefix.
+func matchSGName(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if strings.HasPrefix(p, sgNameRegexPrefix) {
+			re, err := regexp.Compile(strings.TrimPrefix(p, sgNameRegexPrefix))
+			if err != nil {
+				log.Fatalf("Security group name pattern %q error, %v", p, err)
+			}
+			if re.MatchString(name) {
+				return true
+			}
+			continue
+		}
+
+		ok, err := path.Match(p, name)
+		if err != nil {
+			log.Fatalf("Security group name pattern %q error, %v", p, err)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSGTags selector Key=Value (value glob) or Key, all selectors have to match.
+func matchSGTags(selectors []string, tags map[string]string) bool {
+	for _, selector := range selectors {
+		fields := strings.SplitN(selector, "=", 2)
+
+		value, ok := tags[fields[0]]
+		if !ok {
+			return false
+		}
+		if len(fields) == 1 {
+			continue
+		}
+
+		match, err := path.Match(fields[1], value)
+		if err != nil {
+			log.Fatalf("Security group tag selector %q error, %v", selector, err)
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+func (config *SecurityGroupConfig) excluded(c sgCandidate) bool {
+	return containsString(config.ExcludeIDs, c.ID) || matchSGName(config.ExcludeNames, c.Name)
+}
+
+func (config *SecurityGroupConfig) included(c sgCandidate) bool {
+	switch {
+	case len(config.IDs) > 0 && !containsString(config.IDs, c.ID):
+		return false
+	case len(config.VPCIDs) > 0 && !containsString(config.VPCIDs, c.VpcID):
+		return false
+	case len(config.IncludeNames) > 0 && !matchSGName(config.IncludeNames, c.Name):
+		return false
+	}
+	return matchSGTags(config.Tags, c.Tags)
+}
+
+// selectSecurityGroups return selected group ids, include referenced groups transitively,
+// warning if referenced group is excluded.
+func (config *SecurityGroupConfig) selectSecurityGroups(candidates []sgCandidate) map[string]bool {
+	selected := make(map[string]bool)
+	byID := make(map[string]sgCandidate)
+
+	var queue []string
+	for _, c := range candidates {
+		byID[c.ID] = c
+		if config.included(c) && !config.excluded(c) {
+			selected[c.ID] = true
+			queue = append(queue, c.ID)
+		}
+	}
+
+	warned := make(map[string]bool)
+
+	for len(queue) > 0 {
+		c := byID[queue[0]]
+		queue = queue[1:]
+
+		for _, ref := range c.Refs {
+			rc, ok := byID[ref]
+			if !ok || selected[ref] {
+				continue
+			}
+
+			if config.excluded(rc) {
+				if !warned[ref] {
+					log.Printf("Security Group %s(%s) Excluded, But Referenced By %s(%s), Rules Reference It Will Fail.",
+						rc.Name, rc.ID, c.Name, c.ID)
+					warned[ref] = true
+				}
+				continue
+			}
+
+			log.Printf("Security Group %s(%s) Referenced By %s(%s), Include It.", rc.Name, rc.ID, c.Name, c.ID)
+			selected[ref] = true
+			queue = append(queue, ref)
+		}
+	}
+
+	return selected
+}
+
+func newSGCandidate(sg ec2.SecurityGroup) sgCandidate {
+	c := sgCandidate{
+		ID:    aws.StringValue(sg.GroupId),
+		Name:  aws.StringValue(sg.GroupName),
+		VpcID: aws.StringValue(sg.VpcId),
+		Tags:  make(map[string]string),
+	}
+
+	for _, t := range sg.Tags {
+		c.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	for _, ipp := range append(append([]ec2.IpPermission{}, sg.IpPermissions...), sg.IpPermissionsEgress...) {
+		for _, ugp := range ipp.UserIdGroupPairs {
+			if ugp.UserId != nil && aws.StringValue(ugp.UserId) != aws.StringValue(sg.OwnerId) {
+				continue
+			}
+			c.Refs = append(c.Refs, aws.StringValue(ugp.GroupId))
+		}
+	}
+
+	return c
+}
+
+// filterSGList ...
+func filterSGList(sgList []ec2.SecurityGroup, config *SecurityGroupConfig) []ec2.SecurityGroup {
+	if config.empty() {
+		return sgList
+	}
+
+	candidates := []sgCandidate{}
+	for _, sg := range sgList {
+		candidates = append(candidates, newSGCandidate(sg))
+	}
+
+	selected := config.selectSecurityGroups(candidates)
+
+	newSGList := []ec2.SecurityGroup{}
+	for _, sg := range sgList {
+		if selected[aws.StringValue(sg.GroupId)] {
+			newSGList = append(newSGList, sg)
+		}
+	}
+
+	log.Printf("Security Group Filter, Selected: %d / %d", len(newSGList), len(sgList))
+
+	return newSGList
+}
+
+func newSnapshotSGCandidate(sg SnapshotSecurityGroup) sgCandidate {
+	c := sgCandidate{
+		ID:    sg.GroupID,
+		Name:  sg.GroupName,
+		VpcID: sg.VpcID,
+		Tags:  make(map[string]string),
+	}
+
+	for _, t := range sg.Tags {
+		c.Tags[t.Key] = t.Value
+	}
+
+	for _, rule := range sg.Rules {
+		if len(rule.GroupID) > 0 && !strings.Contains(rule.GroupID, "/") {
+			c.Refs = append(c.Refs, rule.GroupID)
+		}
+	}
+
+	return c
+}
+
+// filter snapshot security groups, names keep groups of the names anyway.
+func (snapshot *Snapshot) filter(config *SecurityGroupConfig, names ...string) {
+	if config.empty() {
+		return
+	}
+
+	candidates := []sgCandidate{}
+	for _, sg := range snapshot.SecurityGroups {
+		candidates = append(candidates, newSnapshotSGCandidate(sg))
+	}
+
+	selected := config.selectSecurityGroups(candidates)
+
+	groups := []SnapshotSecurityGroup{}
+	for _, sg := range snapshot.SecurityGroups {
+		if selected[sg.GroupID] || containsString(names, sg.GroupName) {
+			groups = append(groups, sg)
+		}
+	}
+
+	log.Printf("Security Group Filter, Selected: %d / %d", len(groups), len(snapshot.SecurityGroups))
+
+	snapshot.SecurityGroups = groups
+}
+
+// filterDiffSnapshots from side by all filters, renamed as destination if rename, to side by name and
+// tag filters, and the names selected of from side.
+func filterDiffSnapshots(from, to *Snapshot, config *SecurityGroupConfig, rename bool) {
+	if config == nil {
+		return
+	}
+
+	from.filter(config)
+
+	if rename {
+		from.rename(&config.Rename)
+	}
+
+	if config.empty() {
+		return
+	}
+
+	names := []string{}
+	for _, sg := range from.SecurityGroups {
+		names = append(names, sg.GroupName)
+	}
+
+	toConfig := config.withoutIDs()
+	if toConfig.empty() || (rename && !config.Rename.empty()) {
+		// to side select by names.
+		toConfig = &SecurityGroupConfig{IDs: []string{""}}
+	}
+	to.filter(toConfig, names...)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSelectSecurityGroups(t *testing.T) {
+	candidates := []sgCandidate{
+		{ID: "sg-1", Name: "web-prod", VpcID: "vpc-1", Tags: map[string]string{"env": "prod"}, Refs: []string{"sg-2"}},
+		{ID: "sg-2", Name: "db-prod", VpcID: "vpc-1", Tags: map[string]string{"env": "prod"}, Refs: []string{"sg-3"}},
+		{ID: "sg-3", Name: "bastion", VpcID: "vpc-1", Tags: map[string]string{}},
+		{ID: "sg-4", Name: "web-dev", VpcID: "vpc-2", Tags: map[string]string{"env": "dev"}},
+		{ID: "sg-5", Name: "default", VpcID: "vpc-2", Tags: map[string]string{}},
+	}
+
+	tests := []struct {
+		name   string
+		config SecurityGroupConfig
+		want   []string
+	}{
+		{"glob", SecurityGroupConfig{IncludeNames: []string{"web-*"}},
+			[]string{"sg-1", "sg-2", "sg-3", "sg-4"}},
+		{"regex", SecurityGroupConfig{IncludeNames: []string{"re:^web-dev$"}},
+			[]string{"sg-4"}},
+		{"tag value", SecurityGroupConfig{Tags: []string{"env=de*"}},
+			[]string{"sg-4"}},
+		{"tag key", SecurityGroupConfig{Tags: []string{"env"}, VPCIDs: []string{"vpc-2"}},
+			[]string{"sg-4"}},
+		{"vpc", SecurityGroupConfig{VPCIDs: []string{"vpc-2"}},
+			[]string{"sg-4", "sg-5"}},
+		{"ids", SecurityGroupConfig{IDs: []string{"sg-2"}},
+			[]string{"sg-2", "sg-3"}},
+		// excluded referenced group not included.
+		{"exclude referenced", SecurityGroupConfig{IncludeNames: []string{"web-prod"}, ExcludeNames: []string{"bastion"}},
+			[]string{"sg-1", "sg-2"}},
+		{"exclude ids", SecurityGroupConfig{VPCIDs: []string{"vpc-2"}, ExcludeIDs: []string{"sg-5"}},
+			[]string{"sg-4"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for id := range tt.config.selectSecurityGroups(candidates) {
+			got = append(got, id)
+		}
+		sort.Strings(got)
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: selectSecurityGroups = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDestinationFilter(t *testing.T) {
+	config := &SecurityGroupConfig{
+		IncludeNames: []string{"web-*"},
+		Tags:         []string{"env=prod"},
+		VPCIDs:       []string{"vpc-1", "vpc-2", "vpc-3"},
+		IDs:          []string{"sg-1"},
+		ExcludeIDs:   []string{"sg-2"},
+		VPCMap:       map[string]string{"vpc-1": "vpc-a", "vpc-2": "vpc-b"},
+	}
+
+	got := config.destination(&awsAuth{VIPCID: "vpc-a"})
+	want := &SecurityGroupConfig{
+		IncludeNames: []string{"web-*"},
+		Tags:         []string{"env=prod"},
+		VPCIDs:       []string{"vpc-a", "vpc-b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("destination = %+v, want %+v", got, want)
+	}
+
+	if got := (&SecurityGroupConfig{IDs: []string{"sg-1"}}).destination(&awsAuth{VIPCID: "vpc-a"}); len(got.VPCIDs)+len(got.IDs) != 0 {
+		t.Errorf("destination = %+v, want no ids", got)
+	}
+	if got := (*SecurityGroupConfig)(nil).destination(&awsAuth{}); got != nil {
+		t.Errorf("destination = %+v, want nil", got)
+	}
+}
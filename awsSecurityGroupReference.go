@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// SGReferenceReport rule reference can not be mapped, the rule is skipped.
+type SGReferenceReport struct {
+	GroupID   string
+	RuleType  string
+	Reference string
+	Reason    string
+}
+
+func ugpReference(ugp ec2.UserIdGroupPair) string {
+	ref := aws.StringValue(ugp.GroupId)
+	if ugp.UserId != nil {
+		ref = aws.StringValue(ugp.UserId) + "/" + ref
+	}
+	if ugp.VpcPeeringConnectionId != nil {
+		ref += " via " + aws.StringValue(ugp.VpcPeeringConnectionId)
+	}
+	return ref
+}
+
+// mapUGP destination user id group pair, GroupMap first, then migrated groups, groups of other accounts
+// or peered VPCs kept, return reason if unmappable.
+func (awssync *AWSSync) mapUGP(ugp ec2.UserIdGroupPair) (ec2.UserIdGroupPair, string) {
+	refConfig := &awssync.sgConfig.References
+	srcGroupID := aws.StringValue(ugp.GroupId)
+
+	ugp.GroupName = nil
+
+	if dst, ok := refConfig.GroupMap[srcGroupID]; ok {
+		fields := strings.SplitN(dst, "/", 2)
+		ugp.GroupId = aws.String(fields[len(fields)-1])
+		ugp.UserId = nil
+		if len(fields) == 2 {
+			ugp.UserId = aws.String(fields[0])
+		}
+		return awssync.mapPeering(ugp)
+	}
+
+	sameAccount := ugp.UserId == nil || aws.StringValue(ugp.UserId) == sourceOwnerID
+
+	if key, ok := sgIDMameMap[srcGroupID]; ok && sameAccount {
+		newID, ok := newSGNameIDMap[key]
+		if !ok {
+			return ugp, fmt.Sprintf("not found new security group %s in VPC %s", key.Name, key.VpcID)
+		}
+
+		ugp.GroupId = aws.String(newID)
+		ugp.UserId = nil
+		return awssync.mapPeering(ugp)
+	}
+
+	if sameAccount && ugp.VpcPeeringConnectionId == nil {
+		return ugp, "referenced group not migrated, set SecurityGroup References GroupMap"
+	}
+
+	// group of other account or peered VPC, keep it, referenced by account id.
+	if ugp.UserId == nil {
+		ugp.UserId = aws.String(sourceOwnerID)
+	}
+	newUGP, reason := awssync.mapPeering(ugp)
+	if len(reason) == 0 {
+		log.Printf("Keep Security Group Reference %s, Destination VPC Has To Reach It.", ugpReference(newUGP))
+	}
+
+	return newUGP, reason
+}
+
+// mapPeering peering connection id of destination, the reference resolved by VPC peering.
+func (awssync *AWSSync) mapPeering(ugp ec2.UserIdGroupPair) (ec2.UserIdGroupPair, string) {
+	if ugp.VpcPeeringConnectionId == nil {
+		return ugp, ""
+	}
+
+	dst, ok := awssync.sgConfig.References.PeeringMap[aws.StringValue(ugp.VpcPeeringConnectionId)]
+	if !ok {
+		return ugp, fmt.Sprintf("peering connection %s not in SecurityGroup References PeeringMap",
+			aws.StringValue(ugp.VpcPeeringConnectionId))
+	}
+
+	ugp.VpcPeeringConnectionId = aws.String(dst)
+	ugp.VpcId = nil
+	return ugp, ""
+}
+
+func (awssync *AWSSync) reportReference(groupID string, ruleType string, ugp ec2.UserIdGroupPair, reason string) {
+	awssync.references = append(awssync.references, SGReferenceReport{
+		GroupID:   groupID,
+		RuleType:  ruleType,
+		Reference: ugpReference(ugp),
+		Reason:    reason,
+	})
+}
+
+func (awssync *AWSSync) writeReferenceReport() {
+	if len(awssync.references) == 0 {
+		return
+	}
+
+	log.Printf("Unmapped Security Group References, %d Rules Skipped:", len(awssync.references))
+	for _, r := range awssync.references {
+		fmt.Printf("  %s %s -> %s, %s\n", r.GroupID, r.RuleType, r.Reference, r.Reason)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+const sgNameMaxLength = 255
+
+func (config *SGRenameConfig) empty() bool {
+	return config == nil ||
+		(len(config.Map)+len(config.Replace)+len(config.DescriptionReplace) == 0 && len(config.Prefix)+len(config.Suffix) == 0)
+}
+
+func regexReplaceAll(rules []RegexReplace, s string) string {
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Fatalf("Rename pattern %q error, %v", rule.Pattern, err)
+		}
+		s = re.ReplaceAllString(s, rule.Replace)
+	}
+	return s
+}
+
+// name destination group name, default group can not be renamed.
+func (config *SGRenameConfig) name(name string) string {
+	if config.empty() || name == sgDefaultName {
+		return name
+	}
+
+	newName, ok := config.Map[name]
+	if !ok {
+		newName = config.Prefix + regexReplaceAll(config.Replace, name) + config.Suffix
+	}
+
+	switch {
+	case len(newName) == 0:
+		log.Fatalf("Security group %q renamed to empty name.", name)
+	case len(newName) > sgNameMaxLength:
+		log.Fatalf("Security group %q renamed to %q, longer than %d characters.", name, newName, sgNameMaxLength)
+	case strings.HasPrefix(newName, "sg-"):
+		log.Fatalf("Security group %q renamed to %q, name can not start with sg-.", name, newName)
+	}
+
+	return newName
+}
+
+func (config *SGRenameConfig) description(description string) string {
+	if config.empty() {
+		return description
+	}
+	return regexReplaceAll(config.DescriptionReplace, description)
+}
+
+func (config *SGRenameConfig) ruleDescription(description *string) *string {
+	if config.empty() || !config.RuleDescriptions || description == nil {
+		return description
+	}
+	return aws.String(config.description(aws.StringValue(description)))
+}
+
+func (config *SGRenameConfig) renameIPPermissions(ippList []ec2.IpPermission) []ec2.IpPermission {
+	newIppList := []ec2.IpPermission{}
+
+	for _, ipp := range ippList {
+		ipRanges := []ec2.IpRange{}
+		for _, r := range ipp.IpRanges {
+			r.Description = config.ruleDescription(r.Description)
+			ipRanges = append(ipRanges, r)
+		}
+		ipp.IpRanges = ipRanges
+
+		ipv6Ranges := []ec2.Ipv6Range{}
+		for _, r := range ipp.Ipv6Ranges {
+			r.Description = config.ruleDescription(r.Description)
+			ipv6Ranges = append(ipv6Ranges, r)
+		}
+		ipp.Ipv6Ranges = ipv6Ranges
+
+		prefixListIDs := []ec2.PrefixListId{}
+		for _, pl := range ipp.PrefixListIds {
+			pl.Description = config.ruleDescription(pl.Description)
+			prefixListIDs = append(prefixListIDs, pl)
+		}
+		ipp.PrefixListIds = prefixListIDs
+
+		ugps := []ec2.UserIdGroupPair{}
+		for _, ugp := range ipp.UserIdGroupPairs {
+			ugp.Description = config.ruleDescription(ugp.Description)
+			ugps = append(ugps, ugp)
+		}
+		ipp.UserIdGroupPairs = ugps
+
+		newIppList = append(newIppList, ipp)
+	}
+
+	return newIppList
+}
+
+// renameSGList rename groups, group descriptions and rule descriptions, ids not changed.
+func (config *SGRenameConfig) renameSGList(sgList []ec2.SecurityGroup) []ec2.SecurityGroup {
+	if config.empty() {
+		return sgList
+	}
+
+	newSGList := []ec2.SecurityGroup{}
+	for _, sg := range sgList {
+		name := config.name(aws.StringValue(sg.GroupName))
+		if name != aws.StringValue(sg.GroupName) {
+			log.Printf("Rename Security Group %s(%s) To %s.", aws.StringValue(sg.GroupName), aws.StringValue(sg.GroupId), name)
+		}
+
+		sg.GroupName = aws.String(name)
+		sg.Description = aws.String(config.description(aws.StringValue(sg.Description)))
+		sg.IpPermissions = config.renameIPPermissions(sg.IpPermissions)
+		sg.IpPermissionsEgress = config.renameIPPermissions(sg.IpPermissionsEgress)
+
+		newSGList = append(newSGList, sg)
+	}
+
+	return newSGList
+}
+
+// rename snapshot groups as destination, compare with destination.
+func (snapshot *Snapshot) rename(config *SGRenameConfig) {
+	if config.empty() {
+		return
+	}
+
+	for i, sg := range snapshot.SecurityGroups {
+		sg.GroupName = config.name(sg.GroupName)
+		sg.Description = config.description(sg.Description)
+
+		rules := []SGRule{}
+		for _, rule := range sg.Rules {
+			if config.RuleDescriptions {
+				rule.Description = config.description(rule.Description)
+			}
+			rules = append(rules, rule)
+		}
+		sg.Rules = rules
+
+		snapshot.SecurityGroups[i] = sg
+	}
+}
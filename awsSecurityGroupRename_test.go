@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestSGRenameConfigName(t *testing.T) {
+	config := &SGRenameConfig{
+		Map:     map[string]string{"legacy": "web-legacy"},
+		Replace: []RegexReplace{{Pattern: `^prod-`, Replace: "stg-"}},
+		Prefix:  "new-",
+		Suffix:  "-v2",
+	}
+
+	tests := []struct {
+		config *SGRenameConfig
+		name   string
+		want   string
+	}{
+		{config, "legacy", "web-legacy"},
+		{config, "prod-web", "new-stg-web-v2"},
+		{config, "db", "new-db-v2"},
+		// default group can not be renamed.
+		{config, "default", "default"},
+		{nil, "prod-web", "prod-web"},
+		{&SGRenameConfig{}, "prod-web", "prod-web"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.name(tt.name); got != tt.want {
+			t.Errorf("name(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
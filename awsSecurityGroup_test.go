@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func TestMapSourceSGListSharedDefault(t *testing.T) {
+	sgIDMameMap = make(SGIdKeyMapType)
+	awssync := &AWSSync{sgConfig: &SecurityGroupConfig{}}
+	account := &awsAuth{VIPCID: "vpc-dst"}
+
+	rule := func(cidr string) []ec2.IpPermission {
+		return []ec2.IpPermission{{IpProtocol: aws.String("-1"), IpRanges: []ec2.IpRange{{CidrIp: aws.String(cidr)}}}}
+	}
+
+	sgList := []ec2.SecurityGroup{
+		{GroupId: aws.String("sg-1"), GroupName: aws.String("default"), VpcId: aws.String("vpc-a"), IpPermissions: rule("10.0.0.0/16")},
+		{GroupId: aws.String("sg-2"), GroupName: aws.String("web"), VpcId: aws.String("vpc-a")},
+		{GroupId: aws.String("sg-3"), GroupName: aws.String("default"), VpcId: aws.String("vpc-b"), IpPermissions: rule("10.1.0.0/16")},
+	}
+
+	newSGList, dstVPCIDs := awssync.mapSourceSGList(account, sgList)
+
+	if !reflect.DeepEqual(dstVPCIDs, []string{"vpc-dst"}) {
+		t.Errorf("destination VPCs = %v, want [vpc-dst]", dstVPCIDs)
+	}
+	if len(newSGList) != 2 {
+		t.Fatalf("groups = %d, want 2", len(newSGList))
+	}
+	if got := len(newSGList[0].IpPermissions); got != 2 {
+		t.Errorf("shared default group permissions = %d, want 2", got)
+	}
+	if got := len(sgList[0].IpPermissions); got != 1 {
+		t.Errorf("source default group permissions = %d, want 1", got)
+	}
+
+	want := sgKey{VpcID: "vpc-dst", Name: "default"}
+	for _, id := range []string{"sg-1", "sg-3"} {
+		if sgIDMameMap[id] != want {
+			t.Errorf("sgIDMameMap[%s] = %v, want %v", id, sgIDMameMap[id], want)
+		}
+	}
+}
+
+func TestReplaceGroupIDKeepRanges(t *testing.T) {
+	sourceOwnerID = "111111111111"
+	sgIDMameMap = SGIdKeyMapType{
+		"sg-1": {VpcID: "vpc-dst", Name: "web"},
+		"sg-2": {VpcID: "vpc-dst", Name: "db"},
+	}
+	newSGNameIDMap = SGKeyIDMapType{
+		{VpcID: "vpc-dst", Name: "web"}: "sg-new1",
+		{VpcID: "vpc-dst", Name: "db"}:  "sg-new2",
+	}
+	awssync := &AWSSync{sgConfig: &SecurityGroupConfig{}}
+
+	unmapped := ec2.UserIdGroupPair{GroupId: aws.String("sg-9")}
+	ippMap := BuildSGMapType{
+		aws.String("sg-1"): {
+			// unmappable reference, cidr kept.
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(22), ToPort: aws.Int64(22),
+				IpRanges: []ec2.IpRange{{CidrIp: aws.String("10.0.0.0/16")}}, UserIdGroupPairs: []ec2.UserIdGroupPair{unmapped}},
+			// nothing left.
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(80), ToPort: aws.Int64(80),
+				UserIdGroupPairs: []ec2.UserIdGroupPair{unmapped}},
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(5432), ToPort: aws.Int64(5432),
+				UserIdGroupPairs: []ec2.UserIdGroupPair{{GroupId: aws.String("sg-2")}, unmapped}},
+		},
+	}
+
+	var ippList []ec2.IpPermission
+	for gid, list := range awssync.replaceGroupID(ippMap, "ingress") {
+		if aws.StringValue(gid) != "sg-new1" {
+			t.Fatalf("group id = %s, want sg-new1", aws.StringValue(gid))
+		}
+		ippList = list
+	}
+
+	if len(ippList) != 2 {
+		t.Fatalf("permissions = %d, want 2", len(ippList))
+	}
+	if ippList[0].UserIdGroupPairs != nil || aws.StringValue(ippList[0].IpRanges[0].CidrIp) != "10.0.0.0/16" {
+		t.Errorf("permission 22 = %+v, want cidr only", ippList[0])
+	}
+	if len(ippList[1].UserIdGroupPairs) != 1 || aws.StringValue(ippList[1].UserIdGroupPairs[0].GroupId) != "sg-new2" {
+		t.Errorf("permission 5432 = %+v, want sg-new2 only", ippList[1])
+	}
+	if len(awssync.references) != 3 {
+		t.Errorf("reported references = %d, want 3", len(awssync.references))
+	}
+}
+
+func TestReplacePerfixListIDEgress(t *testing.T) {
+	awssync := &AWSSync{perfixListMap: map[string]*PerfixList{
+		"pl-src-1": {newPerfixListID: aws.String("pl-dst-1")},
+		"pl-src-2": {newPerfixListID: aws.String("pl-dst-2")},
+	}}
+
+	sgList := awssync.replacePerfixListID([]ec2.SecurityGroup{{
+		GroupId: aws.String("sg-1"),
+		IpPermissions: []ec2.IpPermission{{
+			IpProtocol:    aws.String("tcp"),
+			PrefixListIds: []ec2.PrefixListId{{PrefixListId: aws.String("pl-src-1")}},
+		}},
+		IpPermissionsEgress: []ec2.IpPermission{{
+			IpProtocol:    aws.String("-1"),
+			PrefixListIds: []ec2.PrefixListId{{PrefixListId: aws.String("pl-src-2"), Description: aws.String("s3")}},
+		}},
+	}})
+
+	if got := aws.StringValue(sgList[0].IpPermissions[0].PrefixListIds[0].PrefixListId); got != "pl-dst-1" {
+		t.Errorf("ingress prefix list = %s, want pl-dst-1", got)
+	}
+	want := []ec2.PrefixListId{{PrefixListId: aws.String("pl-dst-2"), Description: aws.String("s3")}}
+	if got := sgList[0].IpPermissionsEgress[0].PrefixListIds; !reflect.DeepEqual(got, want) {
+		t.Errorf("egress prefix lists = %+v, want %+v", got, want)
+	}
+}
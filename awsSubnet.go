@@ -15,8 +15,8 @@ type SubnetSync struct {
 	srcVPCID   string
 }
 
-// SubnetSyncGO ...
-func SubnetSyncGO(awsAccount *AWSAccount) {
+// SubnetSyncGO create source VPC subnets in Destination VPCID, return source id: destination id.
+func SubnetSyncGO(awsAccount *AWSAccount) map[string]string {
 	var subnetSync SubnetSync
 
 	subnetSync.tagsConfig = awsAccount.Tags
@@ -25,33 +25,96 @@ func SubnetSyncGO(awsAccount *AWSAccount) {
 	subnets := getSubnetsInfo(&awsAccount.Source)
 	newSubnets := subnetSync.filterSubnetByVPCID(subnets)
 
-	subnetSync.createSubnets(&awsAccount.Destination, newSubnets)
+	subnetMap := subnetSync.createSubnets(&awsAccount.Destination, newSubnets)
 
 	log.Print("Subnet Migrate Done.")
+
+	return subnetMap
 }
 
-func (subnetSync *SubnetSync) createSubnets(account *awsAuth, subnets []ec2.Subnet) {
+func (subnetSync *SubnetSync) createSubnets(account *awsAuth, subnets []ec2.Subnet) map[string]string {
 	svc := newSVC(account)
 
+	subnetMap := make(map[string]string)
+
 	for _, subnet := range subnets {
-		req := svc.CreateSubnetRequest(&ec2.CreateSubnetInput{
-			AvailabilityZoneId: subnet.AvailabilityZoneId,
-			CidrBlock:          subnet.CidrBlock,
-			VpcId:              subnet.VpcId,
-			TagSpecifications:  subnetSync.setSGTags(subnet.Tags, ec2.ResourceTypeSubnet),
+		srcID := aws.StringValue(subnet.SubnetId)
+
+		// VPC not created in dry run.
+		if isDryRunID(aws.String(account.VIPCID)) {
+			dryRunRecord("Create Subnet %s In %s", aws.StringValue(subnet.CidrBlock), aws.StringValue(subnet.AvailabilityZoneId))
+			subnetMap[srcID] = aws.StringValue(dryRunID(srcID))
+			continue
+		}
+
+		var result *ec2.CreateSubnetResponse
+		err := awsCall(serviceEC2, "Create Subnet", aws.StringValue(subnet.CidrBlock), nil, func(ctx context.Context) (err error) {
+			result, err = svc.CreateSubnetRequest(&ec2.CreateSubnetInput{
+				DryRun:             aws.Bool(dryRun),
+				AvailabilityZoneId: subnet.AvailabilityZoneId,
+				CidrBlock:          subnet.CidrBlock,
+				VpcId:              aws.String(account.VIPCID),
+				TagSpecifications:  subnetSync.setSGTags(subnet.Tags, ec2.ResourceTypeSubnet),
+			}).Send(ctx)
+			return err
 		})
-		_, err := req.Send(context.Background())
 		if err != nil {
+			if dryRunOK(err, "Create Subnet %s In %s", aws.StringValue(subnet.CidrBlock), aws.StringValue(subnet.AvailabilityZoneId)) {
+				subnetMap[srcID] = aws.StringValue(dryRunID(srcID))
+				continue
+			}
+			// created by previous run, same cidr in the VPC.
+			if errorCode(err) == "InvalidSubnet.Conflict" {
+				if subnetID := getSubnetIDByCIDR(account, aws.StringValue(subnet.CidrBlock)); len(subnetID) > 0 {
+					log.Printf("Subnet %s already exists, reuse %s.", aws.StringValue(subnet.CidrBlock), subnetID)
+					subnetMap[srcID] = subnetID
+					continue
+				}
+			}
 			log.Fatalln(err)
 		}
+
+		subnetMap[srcID] = aws.StringValue(result.Subnet.SubnetId)
 	}
+
+	return subnetMap
+}
+
+// getSubnetIDByCIDR subnet of the cidr in account VPCID, empty if not found.
+func getSubnetIDByCIDR(account *awsAuth, cidr string) string {
+	svc := newSVC(account)
+
+	var result *ec2.DescribeSubnetsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{
+			Filters: []ec2.Filter{
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []string{account.VIPCID},
+				},
+				{
+					Name:   aws.String("cidr-block"),
+					Values: []string{cidr},
+				},
+			},
+		}).Send(ctx)
+		return err
+	})
+	if err != nil || len(result.Subnets) == 0 {
+		return ""
+	}
+
+	return aws.StringValue(result.Subnets[0].SubnetId)
 }
 
 func getSubnetsInfo(account *awsAuth) []ec2.Subnet {
 	svc := newSVC(account)
 
-	req := svc.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{})
-	result, err := req.Send(context.Background())
+	var result *ec2.DescribeSubnetsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{}).Send(ctx)
+		return err
+	})
 	if err != nil {
 		log.Fatalln(err)
 		return nil
@@ -14,7 +14,7 @@ type VPCSync struct {
 	tagsConfig []Tag
 }
 
-// VPCSyncGO ...
+// VPCSyncGO create source VPC in destination, Destination VPCID set to the new VPC.
 func VPCSyncGO(awsAccount *AWSAccount) {
 	var vpcSync VPCSync
 
@@ -22,18 +22,22 @@ func VPCSyncGO(awsAccount *AWSAccount) {
 
 	srcVPC := getVPCsInfo(&awsAccount.Source)[0]
 
-	vpcSync.createVPC(&awsAccount.Destination, srcVPC)
+	newVPCID := vpcSync.createVPC(&awsAccount.Destination, srcVPC)
+	awsAccount.Destination.VIPCID = aws.StringValue(newVPCID)
 
-	log.Print("VPC Migrate Done.")
+	log.Printf("VPC Migrate Done, %s -> %s.", aws.StringValue(srcVPC.VpcId), aws.StringValue(newVPCID))
 }
 
 func getVPCsInfo(account *awsAuth) []ec2.Vpc {
 	svc := newSVC(account)
 
-	req := svc.DescribeVpcsRequest(&ec2.DescribeVpcsInput{
-		VpcIds: []string{account.VIPCID},
+	var result *ec2.DescribeVpcsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeVpcsRequest(&ec2.DescribeVpcsInput{
+			VpcIds: []string{account.VIPCID},
+		}).Send(ctx)
+		return err
 	})
-	result, err := req.Send(context.Background())
 	if err != nil {
 		log.Fatalln(err)
 		return nil
@@ -42,18 +46,32 @@ func getVPCsInfo(account *awsAuth) []ec2.Vpc {
 	return result.Vpcs
 }
 
-func (vpcSync *VPCSync) createVPC(account *awsAuth, vpcInfo ec2.Vpc) {
+func (vpcSync *VPCSync) createVPC(account *awsAuth, vpcInfo ec2.Vpc) *string {
 	svc := newSVC(account)
 
-	req := svc.CreateVpcRequest(&ec2.CreateVpcInput{
-		CidrBlock:         vpcInfo.CidrBlock,
-		TagSpecifications: vpcSync.setSGTags(vpcInfo.Tags, ec2.ResourceTypeVpc),
-	})
-	_, err := req.Send(context.Background())
+	token := clientToken(aws.StringValue(vpcInfo.VpcId))
+
+	var vpcID string
+	err := awsCall(serviceEC2, "Create VPC", aws.StringValue(vpcInfo.CidrBlock), nil, lookupCreate(svc, token, &vpcID, func(ctx context.Context) error {
+		result, err := svc.CreateVpcRequest(&ec2.CreateVpcInput{
+			DryRun:            aws.Bool(dryRun),
+			CidrBlock:         vpcInfo.CidrBlock,
+			TagSpecifications: tokenTagSpecifications(vpcSync.setSGTags(vpcInfo.Tags, ec2.ResourceTypeVpc), ec2.ResourceTypeVpc, token),
+		}).Send(ctx)
+		if err == nil {
+			vpcID = aws.StringValue(result.Vpc.VpcId)
+		}
+		return err
+	}))
 	if err != nil {
+		if dryRunOK(err, "Create VPC %s", aws.StringValue(vpcInfo.CidrBlock)) {
+			return dryRunID("vpc")
+		}
 		log.Fatalln(err)
-		return
+		return nil
 	}
+
+	return aws.String(vpcID)
 }
 
 func (vpcSync *VPCSync) setSGTags(tags []ec2.Tag, resourceType ec2.ResourceType) []ec2.TagSpecification {
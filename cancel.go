@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultCallTimeout = 60 * time.Second
+	defaultWaitTimeout = 30 * time.Minute
+
+	// in-flight calls aborted by context, grace for steps not calling api (e.g. prompt).
+	cancelGracePeriod = 5 * time.Second
+
+	exitCodeInterrupted = 130
+)
+
+var (
+	// rootCtx cancelled on SIGINT / SIGTERM, parent of all api calls.
+	rootCtx = context.Background()
+
+	timeoutConfig = &TimeoutConfig{}
+
+	// changes made, by order.
+	journalLock sync.Mutex
+	journal     []string
+
+	cancelHooks []func()
+	cancelOnce  sync.Once
+)
+
+// watchSignals cancel rootCtx on first signal, second signal quits immediately.
+func watchSignals() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	rootCtx = ctx
+
+	go func() {
+		<-ctx.Done()
+		stop()
+
+		log.Print("Interrupted, Abort Current Step, Press Ctrl-C Again To Force Quit.")
+
+		time.Sleep(cancelGracePeriod)
+		exitIfCancelled()
+	}()
+}
+
+// callTimeout service config, config, default.
+func callTimeout(service string) time.Duration {
+	if t, ok := timeoutConfig.Services[service]; ok && t > 0 {
+		return t
+	}
+	if timeoutConfig.Call > 0 {
+		return timeoutConfig.Call
+	}
+	return defaultCallTimeout
+}
+
+func waitTimeout() time.Duration {
+	if timeoutConfig.Wait > 0 {
+		return timeoutConfig.Wait
+	}
+	return defaultWaitTimeout
+}
+
+// sleepContext sleep d, false if cancelled.
+func sleepContext(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-rootCtx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func journalRecord(format string, args ...interface{}) {
+	journalLock.Lock()
+	defer journalLock.Unlock()
+
+	journal = append(journal, fmt.Sprintf(format, args...))
+}
+
+// onCancel hook run before exit on cancellation, e.g. flush mappings.
+func onCancel(hook func()) {
+	cancelHooks = append(cancelHooks, hook)
+}
+
+// exitIfCancelled run hooks, print completed changes, exit. Other callers block until exit.
+func exitIfCancelled() {
+	if rootCtx.Err() == nil {
+		return
+	}
+
+	cancelOnce.Do(func() {
+		for _, hook := range cancelHooks {
+			hook()
+		}
+
+		journalLock.Lock()
+		defer journalLock.Unlock()
+
+		log.Printf("Cancelled, Completed Changes: %d", len(journal))
+		for i, change := range journal {
+			fmt.Printf("  %d. %s\n", i+1, change)
+		}
+		DryRunSummary()
+
+		os.Exit(exitCodeInterrupted)
+	})
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+type cidrMapping struct {
+	src *net.IPNet
+	dst *net.IPNet
+}
+
+// CIDRTranslation ...
+type CIDRTranslation struct {
+	Resource string `json:"Resource"`
+	From     string `json:"From"`
+	To       string `json:"To,omitempty"`
+	Warning  string `json:"Warning,omitempty"`
+}
+
+// CIDRTranslator ...
+type CIDRTranslator struct {
+	mappings   []cidrMapping
+	reportFile string
+	reports    []CIDRTranslation
+}
+
+func parseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		log.Fatalf("CIDR %q error, %v", cidr, err)
+	}
+	return ipNet
+}
+
+func (ct *CIDRTranslator) add(src, dst string) {
+	srcNet, dstNet := parseCIDR(src), parseCIDR(dst)
+
+	if len(srcNet.IP) != len(dstNet.IP) {
+		log.Fatalf("CIDR map %s -> %s, different address family.", src, dst)
+	}
+
+	// explicit first, the same source cidr not overwritten by auto.
+	for _, m := range ct.mappings {
+		if m.src.String() == srcNet.String() {
+			return
+		}
+	}
+
+	ct.mappings = append(ct.mappings, cidrMapping{src: srcNet, dst: dstNet})
+}
+
+// cidrVPCPairs source VPCID: destination VPCID of auto map, source VPCID and srcVPCIDs mapped by
+// vpcMap, default destination VPCID, empty VPCID skipped.
+func cidrVPCPairs(src *awsAuth, dst *awsAuth, srcVPCIDs []string, vpcMap map[string]string) map[string]string {
+	pairs := make(map[string]string)
+
+	for _, srcVPCID := range append([]string{src.VIPCID}, srcVPCIDs...) {
+		dstVPCID, ok := vpcMap[srcVPCID]
+		if !ok {
+			dstVPCID = dst.VIPCID
+		}
+		if len(srcVPCID) > 0 && len(dstVPCID) > 0 {
+			pairs[srcVPCID] = dstVPCID
+		}
+	}
+	for srcVPCID, dstVPCID := range vpcMap {
+		if len(srcVPCID) > 0 && len(dstVPCID) > 0 {
+			pairs[srcVPCID] = dstVPCID
+		}
+	}
+
+	return pairs
+}
+
+// newCIDRTranslator explicit map, and auto map of source VPCs to destination VPCs, srcVPCIDs and
+// vpcMap (source VPCID: destination VPCID) of SecurityGroup config.
+func newCIDRTranslator(config *CIDRMapConfig, src *awsAuth, dst *awsAuth, srcVPCIDs []string, vpcMap map[string]string) *CIDRTranslator {
+	ct := &CIDRTranslator{reportFile: config.ReportFile}
+
+	keys := make([]string, 0, len(config.Map))
+	for k := range config.Map {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		ct.add(k, config.Map[k])
+	}
+
+	if !config.Auto {
+		return ct
+	}
+
+	pairs := cidrVPCPairs(src, dst, srcVPCIDs, vpcMap)
+	if len(pairs) == 0 {
+		log.Print("Not Found Source And Destination VPCID, Skip Auto CIDR Map.")
+		return ct
+	}
+
+	srcVPCIDs = make([]string, 0, len(pairs))
+	for k := range pairs {
+		srcVPCIDs = append(srcVPCIDs, k)
+	}
+	sort.Strings(srcVPCIDs)
+
+	srcSubnets := getSubnetsInfo(src)
+	dstSubnets := getSubnetsInfo(dst)
+
+	for _, srcVPCID := range srcVPCIDs {
+		dstVPCID := pairs[srcVPCID]
+		if isDryRunID(aws.String(dstVPCID)) {
+			log.Printf("Destination VPC Not Created In Dry Run, Skip Auto CIDR Map Of %s.", srcVPCID)
+			continue
+		}
+
+		srcVPC := getVPCInfo(src, srcVPCID)
+		dstVPC := getVPCInfo(dst, dstVPCID)
+
+		// subnet first, more specific.
+		dstSubnetNames := make(map[string]ec2.Subnet)
+		for _, subnet := range dstSubnets {
+			if aws.StringValue(subnet.VpcId) == dstVPCID && len(tagName(subnet.Tags)) > 0 {
+				dstSubnetNames[tagName(subnet.Tags)] = subnet
+			}
+		}
+
+		for _, subnet := range srcSubnets {
+			if aws.StringValue(subnet.VpcId) != srcVPCID {
+				continue
+			}
+
+			dstSubnet, ok := dstSubnetNames[tagName(subnet.Tags)]
+			if !ok || len(tagName(subnet.Tags)) == 0 {
+				continue
+			}
+
+			ct.add(aws.StringValue(subnet.CidrBlock), aws.StringValue(dstSubnet.CidrBlock))
+
+			if len(subnet.Ipv6CidrBlockAssociationSet) > 0 && len(dstSubnet.Ipv6CidrBlockAssociationSet) > 0 {
+				ct.add(aws.StringValue(subnet.Ipv6CidrBlockAssociationSet[0].Ipv6CidrBlock),
+					aws.StringValue(dstSubnet.Ipv6CidrBlockAssociationSet[0].Ipv6CidrBlock))
+			}
+		}
+
+		srcBlocks, srcIPv6Blocks := vpcCIDRBlocks(srcVPC)
+		dstBlocks, dstIPv6Blocks := vpcCIDRBlocks(dstVPC)
+
+		for i := 0; i < len(srcBlocks) && i < len(dstBlocks); i++ {
+			ct.add(srcBlocks[i], dstBlocks[i])
+		}
+		for i := 0; i < len(srcIPv6Blocks) && i < len(dstIPv6Blocks); i++ {
+			ct.add(srcIPv6Blocks[i], dstIPv6Blocks[i])
+		}
+	}
+
+	for _, m := range ct.mappings {
+		log.Printf("CIDR Map: %s -> %s", m.src, m.dst)
+	}
+
+	return ct
+}
+
+func getVPCInfo(account *awsAuth, vpcID string) ec2.Vpc {
+	vpcs := getVPCsInfo(&awsAuth{
+		AccessKey: account.AccessKey,
+		SecretKey: account.SecretKey,
+		Region:    account.Region,
+		VIPCID:    vpcID,
+	})
+	if len(vpcs) == 0 {
+		log.Fatalf("Not Found VPC %s.", vpcID)
+	}
+	return vpcs[0]
+}
+
+// vpcCIDRBlocks associated ipv4 and ipv6 blocks, by association order.
+func vpcCIDRBlocks(vpc ec2.Vpc) (blocks []string, ipv6Blocks []string) {
+	for _, b := range vpc.CidrBlockAssociationSet {
+		if b.CidrBlockState != nil && b.CidrBlockState.State != ec2.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		blocks = append(blocks, aws.StringValue(b.CidrBlock))
+	}
+	for _, b := range vpc.Ipv6CidrBlockAssociationSet {
+		if b.Ipv6CidrBlockState != nil && b.Ipv6CidrBlockState.State != ec2.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		ipv6Blocks = append(ipv6Blocks, aws.StringValue(b.Ipv6CidrBlock))
+	}
+	return blocks, ipv6Blocks
+}
+
+func (ct *CIDRTranslator) report(resource, from, to, warning string) {
+	ct.reports = append(ct.reports, CIDRTranslation{Resource: resource, From: from, To: to, Warning: warning})
+	if len(warning) > 0 {
+		log.Printf("CIDR Warning: %s %s, %s", resource, from, warning)
+	}
+}
+
+// translateCIDR by the most specific source cidr contains it, host bits kept. cidr contains
+// source cidr (partial overlap) can not be mapped cleanly, warn.
+func (ct *CIDRTranslator) translateCIDR(resource string, cidr *string) *string {
+	if ct == nil || len(ct.mappings) == 0 || cidr == nil {
+		return cidr
+	}
+
+	ip, ipNet, err := net.ParseCIDR(aws.StringValue(cidr))
+	if err != nil {
+		return cidr
+	}
+	ones, bits := ipNet.Mask.Size()
+
+	// any address, nothing to map.
+	if ones == 0 {
+		return cidr
+	}
+
+	var (
+		match    *cidrMapping
+		overlaps []cidrMapping
+	)
+	for i, m := range ct.mappings {
+		srcOnes, srcBits := m.src.Mask.Size()
+		if srcBits != bits {
+			continue
+		}
+
+		switch {
+		case srcOnes <= ones && m.src.Contains(ip):
+			if match == nil {
+				match = &ct.mappings[i]
+			} else if matchOnes, _ := match.src.Mask.Size(); srcOnes > matchOnes {
+				match = &ct.mappings[i]
+			}
+		case srcOnes > ones && ipNet.Contains(m.src.IP):
+			overlaps = append(overlaps, m)
+		}
+	}
+
+	if match == nil {
+		for _, m := range overlaps {
+			ct.report(resource, aws.StringValue(cidr), "", "partial overlap with "+m.src.String()+", not mapped")
+		}
+		return cidr
+	}
+
+	srcOnes, _ := match.src.Mask.Size()
+	dstOnes, _ := match.dst.Mask.Size()
+
+	var newNet *net.IPNet
+	switch {
+	case srcOnes == ones:
+		newNet = match.dst
+	case srcOnes == dstOnes:
+		newIP := make(net.IP, len(match.dst.IP))
+		for i := range newIP {
+			newIP[i] = match.dst.IP[i] | (ipNet.IP[i] &^ match.src.Mask[i])
+		}
+		newNet = &net.IPNet{IP: newIP, Mask: ipNet.Mask}
+	default:
+		ct.report(resource, aws.StringValue(cidr), "", "map "+match.src.String()+" -> "+match.dst.String()+" prefix length differ, not mapped")
+		return cidr
+	}
+
+	// more specific map inside, translated to other place.
+	for _, m := range overlaps {
+		if !newNet.Contains(m.dst.IP) {
+			ct.report(resource, aws.StringValue(cidr), newNet.String(),
+				"partial overlap with "+m.src.String()+" -> "+m.dst.String()+", not covered")
+		}
+	}
+
+	if newNet.String() == aws.StringValue(cidr) {
+		return cidr
+	}
+
+	ct.report(resource, aws.StringValue(cidr), newNet.String(), "")
+	return aws.String(newNet.String())
+}
+
+func (ct *CIDRTranslator) translateIPPermissions(resource string, ippList []ec2.IpPermission) []ec2.IpPermission {
+	newIppList := []ec2.IpPermission{}
+
+	for _, ipp := range ippList {
+		ipRanges := []ec2.IpRange{}
+		for _, r := range ipp.IpRanges {
+			r.CidrIp = ct.translateCIDR(resource, r.CidrIp)
+			ipRanges = append(ipRanges, r)
+		}
+		ipp.IpRanges = ipRanges
+
+		ipv6Ranges := []ec2.Ipv6Range{}
+		for _, r := range ipp.Ipv6Ranges {
+			r.CidrIpv6 = ct.translateCIDR(resource, r.CidrIpv6)
+			ipv6Ranges = append(ipv6Ranges, r)
+		}
+		ipp.Ipv6Ranges = ipv6Ranges
+
+		newIppList = append(newIppList, ipp)
+	}
+
+	return newIppList
+}
+
+// translateSGList ingress and egress rules.
+func (ct *CIDRTranslator) translateSGList(sgList []ec2.SecurityGroup) []ec2.SecurityGroup {
+	if ct == nil || len(ct.mappings) == 0 {
+		return sgList
+	}
+
+	newSGList := []ec2.SecurityGroup{}
+	for _, sg := range sgList {
+		resource := aws.StringValue(sg.GroupName) + "(" + aws.StringValue(sg.GroupId) + ")"
+		sg.IpPermissions = ct.translateIPPermissions(resource+" ingress", sg.IpPermissions)
+		sg.IpPermissionsEgress = ct.translateIPPermissions(resource+" egress", sg.IpPermissionsEgress)
+		newSGList = append(newSGList, sg)
+	}
+
+	return newSGList
+}
+
+func (ct *CIDRTranslator) translatePrefixListEntries(resource string, entries []ec2.PrefixListEntry) []ec2.PrefixListEntry {
+	newEntries := []ec2.PrefixListEntry{}
+	for _, entry := range entries {
+		entry.Cidr = ct.translateCIDR(resource, entry.Cidr)
+		newEntries = append(newEntries, entry)
+	}
+	return newEntries
+}
+
+// translateNetworkACLEntries ipv4 and ipv6 cidr of entries.
+func (ct *CIDRTranslator) translateNetworkACLEntries(resource string, entries []ec2.NetworkAclEntry) []ec2.NetworkAclEntry {
+	newEntries := []ec2.NetworkAclEntry{}
+	for _, entry := range entries {
+		entryResource := fmt.Sprintf("%s rule %d", resource, aws.Int64Value(entry.RuleNumber))
+		entry.CidrBlock = ct.translateCIDR(entryResource, entry.CidrBlock)
+		entry.Ipv6CidrBlock = ct.translateCIDR(entryResource, entry.Ipv6CidrBlock)
+		newEntries = append(newEntries, entry)
+	}
+	return newEntries
+}
+
+// translateSnapshot rules and prefix list entries, compare with destination.
+func (ct *CIDRTranslator) translateSnapshot(snapshot *Snapshot) {
+	if ct == nil || len(ct.mappings) == 0 {
+		return
+	}
+
+	for i, sg := range snapshot.SecurityGroups {
+		for ii, rule := range sg.Rules {
+			resource := sg.GroupName + "(" + sg.GroupID + ") " + rule.Type
+			if len(rule.CidrIPv4) > 0 {
+				snapshot.SecurityGroups[i].Rules[ii].CidrIPv4 = aws.StringValue(ct.translateCIDR(resource, aws.String(rule.CidrIPv4)))
+			}
+			if len(rule.CidrIPv6) > 0 {
+				snapshot.SecurityGroups[i].Rules[ii].CidrIPv6 = aws.StringValue(ct.translateCIDR(resource, aws.String(rule.CidrIPv6)))
+			}
+		}
+	}
+
+	for i, pl := range snapshot.PrefixLists {
+		for ii, entry := range pl.Entries {
+			snapshot.PrefixLists[i].Entries[ii].Cidr = aws.StringValue(ct.translateCIDR("prefix list "+pl.Name, aws.String(entry.Cidr)))
+		}
+	}
+}
+
+func (ct *CIDRTranslator) writeReport() {
+	if ct == nil || len(ct.mappings) == 0 {
+		return
+	}
+
+	if len(ct.reports) == 0 {
+		log.Print("No CIDR Translated.")
+		return
+	}
+
+	for _, r := range ct.reports {
+		if len(r.Warning) == 0 {
+			log.Printf("Translate CIDR: %s %s -> %s", r.Resource, r.From, r.To)
+		}
+	}
+
+	if len(ct.reportFile) == 0 {
+		return
+	}
+
+	buff, err := json.MarshalIndent(ct.reports, "", "  ")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = ioutil.WriteFile(ct.reportFile, buff, 0644)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Output CIDR Report: %s", ct.reportFile)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func TestTranslateCIDR(t *testing.T) {
+	ct := &CIDRTranslator{}
+	ct.add("10.0.1.0/24", "172.16.5.0/24")
+	ct.add("10.0.0.0/16", "172.16.0.0/16")
+	ct.add("10.2.0.0/16", "172.18.0.0/20")
+	ct.add("2600:1f18::/56", "2600:1f14::/56")
+
+	tests := []struct {
+		cidr    string
+		want    string
+		warning bool
+	}{
+		// most specific, host bits kept.
+		{"10.0.1.10/32", "172.16.5.10/32", false},
+		{"10.0.1.0/24", "172.16.5.0/24", false},
+		{"10.0.3.0/24", "172.16.3.0/24", false},
+		{"10.0.0.0/16", "172.16.0.0/16", false},
+		{"2600:1f18::10/128", "2600:1f14::10/128", false},
+		{"0.0.0.0/0", "0.0.0.0/0", false},
+		{"192.168.0.0/24", "192.168.0.0/24", false},
+		// contains source cidr, partial overlap.
+		{"10.0.0.0/8", "10.0.0.0/8", true},
+		// prefix length of map differ.
+		{"10.2.3.0/24", "10.2.3.0/24", true},
+	}
+
+	for _, tt := range tests {
+		ct.reports = nil
+		got := aws.StringValue(ct.translateCIDR("test", aws.String(tt.cidr)))
+		if got != tt.want {
+			t.Errorf("translateCIDR(%s) = %s, want %s", tt.cidr, got, tt.want)
+		}
+
+		warning := false
+		for _, r := range ct.reports {
+			warning = warning || len(r.Warning) > 0
+		}
+		if warning != tt.warning {
+			t.Errorf("translateCIDR(%s) warning %v, want %v, reports %v", tt.cidr, warning, tt.warning, ct.reports)
+		}
+	}
+}
+
+func TestTranslateNetworkACLEntries(t *testing.T) {
+	ct := &CIDRTranslator{}
+	ct.add("10.0.0.0/16", "172.16.0.0/16")
+
+	entries := ct.translateNetworkACLEntries("acl-1", []ec2.NetworkAclEntry{
+		{RuleNumber: aws.Int64(100), CidrBlock: aws.String("10.0.2.0/24")},
+		{RuleNumber: aws.Int64(110), Ipv6CidrBlock: aws.String("::/0")},
+	})
+
+	if got := aws.StringValue(entries[0].CidrBlock); got != "172.16.2.0/24" {
+		t.Errorf("entry 100 cidr = %s, want 172.16.2.0/24", got)
+	}
+	if entries[1].CidrBlock != nil || aws.StringValue(entries[1].Ipv6CidrBlock) != "::/0" {
+		t.Errorf("entry 110 = %v, %v, want nil, ::/0", entries[1].CidrBlock, aws.StringValue(entries[1].Ipv6CidrBlock))
+	}
+}
+
+func TestCIDRVPCPairs(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		dst       string
+		srcVPCIDs []string
+		vpcMap    map[string]string
+		want      map[string]string
+	}{
+		{"single", "vpc-a", "vpc-x", nil, nil,
+			map[string]string{"vpc-a": "vpc-x"}},
+		// multi VPC, Source VPCID empty.
+		{"multi", "", "vpc-x", []string{"vpc-a", "vpc-b"}, map[string]string{"vpc-b": "vpc-y"},
+			map[string]string{"vpc-a": "vpc-x", "vpc-b": "vpc-y"}},
+		{"map only", "", "", []string{"vpc-a", "vpc-b"}, map[string]string{"vpc-b": "vpc-y"},
+			map[string]string{"vpc-b": "vpc-y"}},
+		{"none", "", "", nil, nil,
+			map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		got := cidrVPCPairs(&awsAuth{VIPCID: tt.src}, &awsAuth{VIPCID: tt.dst}, tt.srcVPCIDs, tt.vpcMap)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: cidrVPCPairs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
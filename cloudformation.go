@@ -0,0 +1,471 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	cfnFormatYAML     = "yaml"
+	cfnFormatJSON     = "json"
+	cfnVPCParameter   = "VpcId"
+	cfnLogicalIDLimit = 255
+)
+
+var cfnInvalidLogicalIDChar = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// CloudFormationTemplate ...
+type CloudFormationTemplate struct {
+	AWSTemplateFormatVersion string                             `yaml:"AWSTemplateFormatVersion" json:"AWSTemplateFormatVersion"`
+	Description              string                             `yaml:"Description" json:"Description"`
+	Parameters               map[string]CloudFormationParameter `yaml:"Parameters,omitempty" json:"Parameters,omitempty"`
+	Resources                map[string]CloudFormationResource  `yaml:"Resources" json:"Resources"`
+}
+
+// CloudFormationParameter ...
+type CloudFormationParameter struct {
+	Type        string `yaml:"Type" json:"Type"`
+	Description string `yaml:"Description" json:"Description"`
+}
+
+// CloudFormationResource ...
+type CloudFormationResource struct {
+	Type       string                 `yaml:"Type" json:"Type"`
+	DependsOn  []string               `yaml:"DependsOn,omitempty" json:"DependsOn,omitempty"`
+	Properties map[string]interface{} `yaml:"Properties" json:"Properties"`
+}
+
+// CloudFormationExport ...
+type CloudFormationExport struct {
+	tags     *[]Tag
+	template *CloudFormationTemplate
+
+	// aws id: logical id
+	logicalIDs map[string]string
+}
+
+func newCloudFormationExport(tags *[]Tag) *CloudFormationExport {
+	return &CloudFormationExport{
+		tags: tags,
+		template: &CloudFormationTemplate{
+			AWSTemplateFormatVersion: "2010-09-09",
+			Description:              "Export By go-aws-migrate, " + time.Now().Format(time.RFC3339),
+			Parameters:               make(map[string]CloudFormationParameter),
+			Resources:                make(map[string]CloudFormationResource),
+		},
+		logicalIDs: make(map[string]string),
+	}
+}
+
+// register the aws id, return unique logical id, e.g. SecurityGroupWebApp.
+func (cfn *CloudFormationExport) register(prefix string, id string, name string) string {
+	if logicalID, ok := cfn.logicalIDs[id]; ok {
+		return logicalID
+	}
+
+	if len(name) == 0 {
+		name = id
+	}
+
+	words := cfnInvalidLogicalIDChar.Split(name, -1)
+	for i, w := range words {
+		if len(w) > 0 {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+
+	base := prefix + strings.Join(words, "")
+	if len(base) > cfnLogicalIDLimit-4 {
+		base = base[:cfnLogicalIDLimit-4]
+	}
+
+	logicalID := base
+	for i := 2; cfn.used(logicalID); i++ {
+		logicalID = fmt.Sprintf("%s%d", base, i)
+	}
+
+	cfn.logicalIDs[id] = logicalID
+	// reserve logical id, resource add later.
+	cfn.template.Resources[logicalID] = CloudFormationResource{}
+
+	return logicalID
+}
+
+func (cfn *CloudFormationExport) used(logicalID string) bool {
+	_, ok := cfn.template.Resources[logicalID]
+	if !ok {
+		_, ok = cfn.template.Parameters[logicalID]
+	}
+	return ok
+}
+
+// ref return Ref if aws id in export, otherwise the id.
+func (cfn *CloudFormationExport) ref(id string) interface{} {
+	if logicalID, ok := cfn.logicalIDs[id]; ok {
+		return map[string]interface{}{"Ref": logicalID}
+	}
+	return id
+}
+
+// getAtt return Fn::GetAtt if aws id in export, otherwise the id.
+func (cfn *CloudFormationExport) getAtt(id string, attr string) interface{} {
+	if logicalID, ok := cfn.logicalIDs[id]; ok {
+		return map[string]interface{}{"Fn::GetAtt": []string{logicalID, attr}}
+	}
+	return id
+}
+
+// vpcRef VPC not in export, use parameter.
+func (cfn *CloudFormationExport) vpcRef(vpcID string) interface{} {
+	if _, ok := cfn.logicalIDs[vpcID]; ok {
+		return cfn.ref(vpcID)
+	}
+
+	cfn.template.Parameters[cfnVPCParameter] = CloudFormationParameter{
+		Type:        "AWS::EC2::VPC::Id",
+		Description: "VPC ID Of Security Groups And Subnets, Source: " + vpcID,
+	}
+	return map[string]interface{}{"Ref": cfnVPCParameter}
+}
+
+func (cfn *CloudFormationExport) add(logicalID string, resourceType string, properties map[string]interface{}, dependsOn ...string) {
+	cfn.template.Resources[logicalID] = CloudFormationResource{
+		Type:       resourceType,
+		DependsOn:  dependsOn,
+		Properties: properties,
+	}
+}
+
+func (cfn *CloudFormationExport) cfnTags(tags []ec2.Tag) []map[string]string {
+	var cfnTags []map[string]string
+	for _, t := range mergeTags(tags, cfn.tags) {
+		cfnTags = append(cfnTags, map[string]string{"Key": t.Key, "Value": t.Value})
+	}
+	return cfnTags
+}
+
+func (cfn *CloudFormationExport) addVPC(vpc ec2.Vpc) []string {
+	vpcID := aws.StringValue(vpc.VpcId)
+	logicalID := cfn.logicalIDs[vpcID]
+
+	cfn.add(logicalID, "AWS::EC2::VPC", map[string]interface{}{
+		"CidrBlock":       aws.StringValue(vpc.CidrBlock),
+		"InstanceTenancy": string(vpc.InstanceTenancy),
+		"Tags":            cfn.cfnTags(vpc.Tags),
+	})
+
+	var cidrIDs []string
+	for _, cba := range vpc.CidrBlockAssociationSet {
+		if aws.StringValue(cba.CidrBlock) == aws.StringValue(vpc.CidrBlock) ||
+			cba.CidrBlockState == nil || cba.CidrBlockState.State != ec2.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+
+		cidrID := cfn.register(logicalID+"Cidr", aws.StringValue(cba.AssociationId), aws.StringValue(cba.CidrBlock))
+		cfn.add(cidrID, "AWS::EC2::VPCCidrBlock", map[string]interface{}{
+			"VpcId":     cfn.ref(vpcID),
+			"CidrBlock": aws.StringValue(cba.CidrBlock),
+		})
+		cidrIDs = append(cidrIDs, cidrID)
+	}
+
+	return cidrIDs
+}
+
+func (cfn *CloudFormationExport) addSubnet(subnet ec2.Subnet, dependsOn []string) {
+	cfn.add(cfn.logicalIDs[aws.StringValue(subnet.SubnetId)], "AWS::EC2::Subnet", map[string]interface{}{
+		"VpcId":               cfn.vpcRef(aws.StringValue(subnet.VpcId)),
+		"CidrBlock":           aws.StringValue(subnet.CidrBlock),
+		"AvailabilityZoneId":  aws.StringValue(subnet.AvailabilityZoneId),
+		"MapPublicIpOnLaunch": aws.BoolValue(subnet.MapPublicIpOnLaunch),
+		"Tags":                cfn.cfnTags(subnet.Tags),
+	}, dependsOn...)
+}
+
+func (cfn *CloudFormationExport) addPrefixList(pl *PerfixList) {
+	entries := []map[string]string{}
+	for _, e := range pl.PrefixListEntry {
+		entry := map[string]string{"Cidr": aws.StringValue(e.Cidr)}
+		if e.Description != nil {
+			entry["Description"] = aws.StringValue(e.Description)
+		}
+		entries = append(entries, entry)
+	}
+
+	cfn.add(cfn.logicalIDs[aws.StringValue(pl.OldPerfixListID)], "AWS::EC2::PrefixList", map[string]interface{}{
+		"PrefixListName": aws.StringValue(pl.ManagedPrefixList.PrefixListName),
+		"AddressFamily":  aws.StringValue(pl.ManagedPrefixList.AddressFamily),
+		"MaxEntries":     aws.Int64Value(pl.ManagedPrefixList.MaxEntries),
+		"Entries":        entries,
+		"Tags":           cfn.cfnTags(pl.ManagedPrefixList.Tags),
+	})
+}
+
+func cfnRuleProperties(rule SGRule) map[string]interface{} {
+	properties := map[string]interface{}{
+		"IpProtocol": rule.Protocol,
+		"FromPort":   rule.FromPort,
+		"ToPort":     rule.ToPort,
+	}
+	if len(rule.Description) > 0 {
+		properties["Description"] = rule.Description
+	}
+	return properties
+}
+
+// addSecurityGroup cidr and prefix list rules inline, security group references use
+// AWS::EC2::SecurityGroupIngress / AWS::EC2::SecurityGroupEgress to break circular reference.
+func (cfn *CloudFormationExport) addSecurityGroup(sg ec2.SecurityGroup) {
+	sgID := aws.StringValue(sg.GroupId)
+	logicalID := cfn.logicalIDs[sgID]
+
+	ingress := []map[string]interface{}{}
+	egress := []map[string]interface{}{}
+	ruleCount := 0
+
+	for _, rule := range flattenSGRules(sg) {
+		properties := cfnRuleProperties(rule)
+
+		if rule.Self || len(rule.GroupID) > 0 {
+			groupID, ownerID := rule.GroupID, ""
+			if rule.Self {
+				groupID = sgID
+			}
+			if i := strings.Index(groupID, "/"); i > 0 {
+				ownerID, groupID = groupID[:i], groupID[i+1:]
+			}
+
+			properties["GroupId"] = cfn.getAtt(sgID, "GroupId")
+
+			resourceType := "AWS::EC2::SecurityGroupIngress"
+			if rule.Type == "egress" {
+				resourceType = "AWS::EC2::SecurityGroupEgress"
+				properties["DestinationSecurityGroupId"] = cfn.getAtt(groupID, "GroupId")
+			} else {
+				properties["SourceSecurityGroupId"] = cfn.getAtt(groupID, "GroupId")
+				if len(ownerID) > 0 {
+					properties["SourceSecurityGroupOwnerId"] = ownerID
+				}
+			}
+
+			ruleCount++
+			ruleID := cfn.register(logicalID+strings.Title(rule.Type), fmt.Sprintf("%s/%s/%d", sgID, rule.Type, ruleCount), fmt.Sprint(ruleCount))
+			cfn.add(ruleID, resourceType, properties)
+			continue
+		}
+
+		switch {
+		case len(rule.CidrIPv4) > 0:
+			properties["CidrIp"] = rule.CidrIPv4
+		case len(rule.CidrIPv6) > 0:
+			properties["CidrIpv6"] = rule.CidrIPv6
+		case rule.Type == "egress":
+			properties["DestinationPrefixListId"] = cfn.ref(rule.PrefixListID)
+		default:
+			properties["SourcePrefixListId"] = cfn.ref(rule.PrefixListID)
+		}
+
+		if rule.Type == "egress" {
+			egress = append(egress, properties)
+		} else {
+			ingress = append(ingress, properties)
+		}
+	}
+
+	// without egress rule, cloudformation keep the default allow all egress. Group reference egress
+	// is added by AWS::EC2::SecurityGroupEgress, no placeholder.
+	if len(sg.IpPermissionsEgress) == 0 {
+		egress = append(egress, map[string]interface{}{
+			"CidrIp":      "127.0.0.1/32",
+			"IpProtocol":  "-1",
+			"Description": "Disallow Default Egress",
+		})
+	}
+
+	cfn.add(logicalID, "AWS::EC2::SecurityGroup", map[string]interface{}{
+		"GroupName":            aws.StringValue(sg.GroupName),
+		"GroupDescription":     aws.StringValue(sg.Description),
+		"VpcId":                cfn.vpcRef(aws.StringValue(sg.VpcId)),
+		"SecurityGroupIngress": ingress,
+		"SecurityGroupEgress":  egress,
+		"Tags":                 cfn.cfnTags(sg.Tags),
+	})
+}
+
+func (cfn *CloudFormationExport) addHostedZone(account *awsAuth, res *ExportResources) {
+	zoneID := trimHostedZoneID(aws.StringValue(res.HostedZone.Id))
+	zoneLogicalID := cfn.logicalIDs[zoneID]
+
+	properties := map[string]interface{}{
+		"Name":           aws.StringValue(res.HostedZone.Name),
+		"HostedZoneTags": cfn.cfnTags(res.HostedZoneTags),
+	}
+	if res.HostedZone.Config != nil {
+		if res.HostedZone.Config.Comment != nil {
+			properties["HostedZoneConfig"] = map[string]string{"Comment": aws.StringValue(res.HostedZone.Config.Comment)}
+		}
+		if aws.BoolValue(res.HostedZone.Config.PrivateZone) {
+			properties["VPCs"] = []map[string]interface{}{
+				{
+					"VPCId":     cfn.vpcRef(account.VIPCID),
+					"VPCRegion": map[string]string{"Ref": "AWS::Region"},
+				},
+			}
+		}
+	}
+	cfn.add(zoneLogicalID, "AWS::Route53::HostedZone", properties)
+
+	for i, rr := range res.RecordSets {
+		name := strings.Replace(aws.StringValue(rr.Name), `\052`, "*", -1)
+
+		properties := map[string]interface{}{
+			"HostedZoneId": cfn.ref(zoneID),
+			"Name":         name,
+			"Type":         string(rr.Type),
+		}
+		if rr.TTL != nil {
+			properties["TTL"] = fmt.Sprint(aws.Int64Value(rr.TTL))
+		}
+		if len(rr.ResourceRecords) > 0 {
+			values := []string{}
+			for _, v := range rr.ResourceRecords {
+				values = append(values, aws.StringValue(v.Value))
+			}
+			properties["ResourceRecords"] = values
+		}
+		if rr.AliasTarget != nil {
+			properties["AliasTarget"] = map[string]interface{}{
+				"DNSName":              aws.StringValue(rr.AliasTarget.DNSName),
+				"HostedZoneId":         cfn.ref(aws.StringValue(rr.AliasTarget.HostedZoneId)),
+				"EvaluateTargetHealth": aws.BoolValue(rr.AliasTarget.EvaluateTargetHealth),
+			}
+		}
+		if rr.SetIdentifier != nil {
+			properties["SetIdentifier"] = aws.StringValue(rr.SetIdentifier)
+		}
+		if rr.Weight != nil {
+			properties["Weight"] = aws.Int64Value(rr.Weight)
+		}
+		if len(rr.Failover) > 0 {
+			properties["Failover"] = string(rr.Failover)
+		}
+		if len(rr.Region) > 0 {
+			properties["Region"] = string(rr.Region)
+		}
+		if rr.MultiValueAnswer != nil {
+			properties["MultiValueAnswer"] = aws.BoolValue(rr.MultiValueAnswer)
+		}
+		if rr.HealthCheckId != nil {
+			properties["HealthCheckId"] = aws.StringValue(rr.HealthCheckId)
+		}
+		if rr.GeoLocation != nil {
+			geo := map[string]string{}
+			if rr.GeoLocation.ContinentCode != nil {
+				geo["ContinentCode"] = aws.StringValue(rr.GeoLocation.ContinentCode)
+			}
+			if rr.GeoLocation.CountryCode != nil {
+				geo["CountryCode"] = aws.StringValue(rr.GeoLocation.CountryCode)
+			}
+			if rr.GeoLocation.SubdivisionCode != nil {
+				geo["SubdivisionCode"] = aws.StringValue(rr.GeoLocation.SubdivisionCode)
+			}
+			properties["GeoLocation"] = geo
+		}
+
+		recordID := cfn.register("Record", fmt.Sprintf("%s/%d", zoneID, i),
+			strings.Join([]string{strings.Replace(name, "*", "Wildcard", -1), string(rr.Type), aws.StringValue(rr.SetIdentifier)}, " "))
+		cfn.add(recordID, "AWS::Route53::RecordSet", properties)
+	}
+}
+
+// ExportCloudFormation ...
+func ExportCloudFormation(account *awsAuth, filePath string, resources []string, format string, tags *[]Tag) {
+	switch format {
+	case cfnFormatYAML, cfnFormatJSON:
+	default:
+		log.Fatalf("Unknown cloudformation format: %s", format)
+	}
+
+	cfn := newCloudFormationExport(tags)
+	res := getExportResources(account, resources)
+
+	// register all resource first, reference is independent of add order.
+	for _, vpc := range res.VPCs {
+		cfn.register("VPC", aws.StringValue(vpc.VpcId), tagName(vpc.Tags))
+	}
+	for _, subnet := range res.Subnets {
+		cfn.register("Subnet", aws.StringValue(subnet.SubnetId), tagName(subnet.Tags))
+	}
+	for _, pl := range res.PrefixLists {
+		cfn.register("PrefixList", aws.StringValue(pl.OldPerfixListID), aws.StringValue(pl.ManagedPrefixList.PrefixListName))
+	}
+
+	var sgList []ec2.SecurityGroup
+	for _, sg := range res.SecurityGroups {
+		// default security group create by vpc, can not be created.
+		if aws.StringValue(sg.GroupName) == sgDefaultName {
+			log.Printf("Security Group %s(%s), Ignore Default Security Group.", aws.StringValue(sg.GroupName), aws.StringValue(sg.GroupId))
+			continue
+		}
+		sgList = append(sgList, sg)
+		cfn.register("SecurityGroup", aws.StringValue(sg.GroupId), aws.StringValue(sg.GroupName))
+	}
+	if res.HostedZone != nil {
+		cfn.register("HostedZone", trimHostedZoneID(aws.StringValue(res.HostedZone.Id)), aws.StringValue(res.HostedZone.Name))
+	}
+
+	if len(res.RouteTables) > 0 {
+		log.Print("CloudFormation Export Unsupported Route Tables, Ignore.")
+	}
+
+	// subnet in secondary cidr, has to wait the cidr associated.
+	var cidrIDs []string
+	for _, vpc := range res.VPCs {
+		cidrIDs = append(cidrIDs, cfn.addVPC(vpc)...)
+	}
+	for _, subnet := range res.Subnets {
+		cfn.addSubnet(subnet, cidrIDs)
+	}
+	for _, pl := range res.PrefixLists {
+		cfn.addPrefixList(pl)
+	}
+	for _, sg := range sgList {
+		cfn.addSecurityGroup(sg)
+	}
+	if res.HostedZone != nil {
+		cfn.addHostedZone(account, res)
+	}
+
+	var (
+		buff []byte
+		err  error
+	)
+
+	fileName := "CloudFormation-" + time.Now().Format("20060102150405") + "." + format
+	if format == cfnFormatJSON {
+		buff, err = json.MarshalIndent(cfn.template, "", "  ")
+	} else {
+		buff, err = yaml.Marshal(cfn.template)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fileName = filepath.Join(filePath, fileName)
+	err = ioutil.WriteFile(fileName, buff, 0644)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Output File: %s, Export Done.", fileName)
+}
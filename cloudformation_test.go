@@ -0,0 +1,110 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func TestCloudFormationSecurityGroupReferences(t *testing.T) {
+	cfn := newCloudFormationExport(nil)
+	cfn.register("SecurityGroup", "sg-web", "web")
+	cfn.register("SecurityGroup", "sg-db", "db")
+	cfn.register("PrefixList", "pl-1", "office")
+
+	cfn.addSecurityGroup(ec2.SecurityGroup{
+		GroupId:   aws.String("sg-web"),
+		GroupName: aws.String("web"),
+		VpcId:     aws.String("vpc-1"),
+		IpPermissions: []ec2.IpPermission{
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(22), ToPort: aws.Int64(22),
+				PrefixListIds: []ec2.PrefixListId{{PrefixListId: aws.String("pl-1")}}},
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(443), ToPort: aws.Int64(443),
+				UserIdGroupPairs: []ec2.UserIdGroupPair{{GroupId: aws.String("sg-web")}}},
+		},
+		IpPermissionsEgress: []ec2.IpPermission{
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(5432), ToPort: aws.Int64(5432),
+				UserIdGroupPairs: []ec2.UserIdGroupPair{{GroupId: aws.String("sg-db")}, {GroupId: aws.String("sg-other")}}},
+		},
+	})
+
+	resources := cfn.template.Resources
+	getAtt := func(logicalID string) interface{} {
+		return map[string]interface{}{"Fn::GetAtt": []string{logicalID, "GroupId"}}
+	}
+
+	sg := resources["SecurityGroupWeb"]
+	ingress := sg.Properties["SecurityGroupIngress"].([]map[string]interface{})
+	if len(ingress) != 1 || !reflect.DeepEqual(ingress[0]["SourcePrefixListId"], map[string]interface{}{"Ref": "PrefixListOffice"}) {
+		t.Errorf("inline ingress = %v, want prefix list Ref", ingress)
+	}
+	// egress only by group references, no placeholder.
+	if egress := sg.Properties["SecurityGroupEgress"].([]map[string]interface{}); len(egress) != 0 {
+		t.Errorf("inline egress = %v, want none", egress)
+	}
+
+	tests := []struct {
+		logicalID string
+		typ       string
+		key       string
+		want      interface{}
+	}{
+		{"SecurityGroupWebIngress1", "AWS::EC2::SecurityGroupIngress", "SourceSecurityGroupId", getAtt("SecurityGroupWeb")},
+		{"SecurityGroupWebEgress2", "AWS::EC2::SecurityGroupEgress", "DestinationSecurityGroupId", getAtt("SecurityGroupDb")},
+		// group not in export, id kept.
+		{"SecurityGroupWebEgress3", "AWS::EC2::SecurityGroupEgress", "DestinationSecurityGroupId", "sg-other"},
+	}
+
+	for _, tt := range tests {
+		r, ok := resources[tt.logicalID]
+		if !ok {
+			t.Errorf("resource %s not found in %v", tt.logicalID, resources)
+			continue
+		}
+		if r.Type != tt.typ {
+			t.Errorf("%s type = %s, want %s", tt.logicalID, r.Type, tt.typ)
+		}
+		if !reflect.DeepEqual(r.Properties[tt.key], tt.want) {
+			t.Errorf("%s %s = %v, want %v", tt.logicalID, tt.key, r.Properties[tt.key], tt.want)
+		}
+		if !reflect.DeepEqual(r.Properties["GroupId"], getAtt("SecurityGroupWeb")) {
+			t.Errorf("%s GroupId = %v, want GetAtt of SecurityGroupWeb", tt.logicalID, r.Properties["GroupId"])
+		}
+	}
+}
+
+func TestCloudFormationEgressPlaceholder(t *testing.T) {
+	cidrEgress := []ec2.IpPermission{
+		{IpProtocol: aws.String("-1"), IpRanges: []ec2.IpRange{{CidrIp: aws.String("10.0.0.0/8")}}},
+	}
+
+	tests := []struct {
+		name   string
+		egress []ec2.IpPermission
+		want   []string
+	}{
+		{"no egress", nil, []string{"127.0.0.1/32"}},
+		{"cidr egress", cidrEgress, []string{"10.0.0.0/8"}},
+	}
+
+	for _, tt := range tests {
+		cfn := newCloudFormationExport(nil)
+		cfn.register("SecurityGroup", "sg-web", "web")
+		cfn.addSecurityGroup(ec2.SecurityGroup{
+			GroupId:             aws.String("sg-web"),
+			GroupName:           aws.String("web"),
+			VpcId:               aws.String("vpc-1"),
+			IpPermissionsEgress: tt.egress,
+		})
+
+		var got []string
+		for _, e := range cfn.template.Resources["SecurityGroupWeb"].Properties["SecurityGroupEgress"].([]map[string]interface{}) {
+			got = append(got, e["CidrIp"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: egress cidr = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -17,9 +17,16 @@ type AWSSync struct {
 	sourceSGLists []ec2.SecurityGroup
 	perfixListMap map[string]*PerfixList
 	tagsConfig    []Tag
+	sgConfig      *SecurityGroupConfig
+	cidr          *CIDRTranslator
+	references    []SGReferenceReport
 }
 
+const appVersion = "0.6"
+
 var (
+	assumeYes  bool
+	dryRun     bool
 	updateMode bool
 	sourceSGID string
 	yamlConfig *YamlConfig
@@ -29,7 +36,7 @@ var (
 func CommnadRun() {
 	app := &cli.App{
 		Name:    "AWS Migrate Tools",
-		Version: "0.6",
+		Version: appVersion,
 		Usage:   "Command Line",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -38,6 +45,35 @@ func CommnadRun() {
 				Value:   "config.yaml",
 				Usage:   "Load configuration from `FILE`",
 			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y", "non-interactive"},
+				Usage:   "Assume yes to all confirmation, for CI or cron",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Check all changes without making them, same as config DryRun",
+			},
+			&cli.IntFlag{
+				Name:  "workers",
+				Usage: "Parallel `N` api calls, same as config Concurrency Workers (default: 4)",
+			},
+			&cli.Float64Flag{
+				Name:  "rate",
+				Usage: "Api requests per second `RATE` of each service, same as config Concurrency Rate (default: 5)",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			assumeYes = c.Bool("yes")
+			dryRun = c.Bool("dry-run")
+			workers = c.Int("workers")
+			apiRate = c.Float64("rate")
+			watchSignals()
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			DryRunSummary()
+			return nil
 		},
 		Commands: []*cli.Command{
 			{
@@ -45,7 +81,7 @@ func CommnadRun() {
 				Aliases: []string{"sg"},
 				Usage:   "Security Groups Migrate",
 				Action:  handelSG,
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.BoolFlag{
 						Name:    "update",
 						Aliases: []string{"u"},
@@ -55,6 +91,10 @@ func CommnadRun() {
 						Name:  "DontTouchThisButton",
 						Usage: "Clean Destination Security Group Rule, Don't Try It.",
 					},
+					&cli.StringFlag{
+						Name:  "confirm-account",
+						Usage: "Destination `ACCOUNTID`, required by DontTouchThisButton.",
+					},
 					&cli.StringFlag{
 						Name:  "sid",
 						Usage: "Just SYNC This Security Group ID (Experiment).",
@@ -87,15 +127,58 @@ func CommnadRun() {
 						Usage:  "Restore Destination Security Group From File.",
 						Hidden: true,
 					},
+					&cli.BoolFlag{
+						Name:   "restore-by-name",
+						Usage:  "Restore Match Security Groups By Name In Config VPCID, Create Missing Groups And Prefix Lists.",
+						Hidden: true,
+					},
 					&cli.BoolFlag{
 						Name:    "terraform-export",
 						Aliases: []string{"tf"},
 						Usage:   "Export terraform to file, has to be used with export args.",
 					},
+					&cli.StringFlag{
+						Name:  "terraform-import",
+						Usage: "Adopt existing resources to terraform state, `MODE`: block (import blocks), script (terraform import commands).",
+					},
+					&cli.StringFlag{
+						Name:  "terraform-style",
+						Value: tfStyleInline,
+						Usage: "Security group rule `STYLE`: inline, rule (aws_security_group_rule), vpc-rule (aws_vpc_security_group_ingress/egress_rule).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "template",
+						Usage: "Custom template `PATH`, file or directory of *.tmpl, override embedded templates (Repeatable).",
+					},
+					&cli.StringFlag{
+						Name:  "template-ext",
+						Usage: "Output file `EXT` of custom main template.",
+					},
 					&cli.BoolFlag{
 						Name:  "diff",
 						Usage: "Compare source and destination security group.",
 					},
+				}, sgFilterFlags()...),
+				Subcommands: []*cli.Command{
+					{
+						Name:   "diff",
+						Usage:  "Compare security group snapshot file with snapshot file or live account",
+						Action: handelSGDiff,
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:  "from",
+								Usage: "Snapshot `FILE` of compare base.",
+							},
+							&cli.StringFlag{
+								Name:  "to",
+								Usage: "Snapshot `FILE` compare to.",
+							},
+							&cli.StringFlag{
+								Name:  "live",
+								Usage: "Compare to live `ACCOUNT`: src, dst.",
+							},
+						}, sgFilterFlags()...),
+					},
 				},
 			},
 			{
@@ -103,6 +186,143 @@ func CommnadRun() {
 				Aliases: []string{"r53"},
 				Usage:   "Route53 Migrate",
 				Action:  handelR53,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "include-type",
+						Usage: "Only Migrate Record `TYPE` (Repeatable).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-type",
+						Usage: "Do Not Migrate Record `TYPE` (Repeatable).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "include-name",
+						Usage: "Only Migrate Record Name Match `PATTERN`, e.g. *.internal.old.com (Repeatable).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-name",
+						Usage: "Do Not Migrate Record Name Match `PATTERN` (Repeatable).",
+					},
+					&cli.StringFlag{
+						Name:  "zone-name",
+						Usage: "Destination Hosted Zone `NAME` When Create It, Record Suffix Will Be Rewrite.",
+					},
+				},
+			},
+			{
+				Name:    "Resolver",
+				Aliases: []string{"r53r"},
+				Usage:   "Route53 Resolver Endpoints And Rules Migrate",
+				Action:  handelResolver,
+			},
+			{
+				Name:    "Migrate",
+				Aliases: []string{"mg"},
+				Usage:   "Migrate Resources By Manifest, In Dependency Order",
+				Action:  handelMigrate,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "manifest",
+						Aliases: []string{"m"},
+						Usage:   "Manifest `FILE`, yaml of Resources and MappingFile.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "resource",
+						Usage: "Migrate `RESOURCE`, override manifest: all, vpc, dhcp, igw, subnet, route-table, nacl, prefix-list, sg, endpoint, resolver, route53 (Repeatable).",
+					},
+					&cli.StringFlag{
+						Name:  "mapping-file",
+						Usage: "Output source id: destination id of every step to `FILE`.",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-preflight",
+						Usage: "Do Not Check Destination Quotas First.",
+					},
+				}, sgFilterFlags()...),
+			},
+			{
+				Name:    "Preflight",
+				Aliases: []string{"pf"},
+				Usage:   "Check Destination Quotas Against Source Before Migrate",
+				Action:  handelPreflight,
+				Flags: append([]cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "resource",
+						Usage: "Check `RESOURCE`: all, subnet, sg, route53 (Repeatable).",
+						Value: cli.NewStringSlice(exportResourceAll),
+					},
+					&cli.BoolFlag{
+						Name:    "update",
+						Aliases: []string{"u"},
+						Usage:   "Security Groups Sync Update Mode, Existing Groups Are Not Conflicts.",
+					},
+				}, sgFilterFlags()...),
+			},
+			{
+				Name:    "Terraform",
+				Aliases: []string{"tf"},
+				Usage:   "Export VPC, Subnets, Route Tables, Prefix Lists, Security Groups And Route53 To Terraform",
+				Action:  handelTerraform,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dst",
+						Usage: "Export Destination, Default Source.",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Output File Location.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "resource",
+						Usage: "Export `RESOURCE`: all, vpc, subnet, route-table, prefix-list, sg, route53 (Repeatable).",
+						Value: cli.NewStringSlice(exportResourceAll),
+					},
+					&cli.StringFlag{
+						Name:  "terraform-import",
+						Usage: "Adopt existing resources to terraform state, `MODE`: block (import blocks), script (terraform import commands).",
+					},
+					&cli.StringFlag{
+						Name:  "terraform-style",
+						Value: tfStyleInline,
+						Usage: "Security group rule `STYLE`: inline, rule (aws_security_group_rule), vpc-rule (aws_vpc_security_group_ingress/egress_rule).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "template",
+						Usage: "Custom template `PATH`, file or directory of *.tmpl, override embedded templates (Repeatable).",
+					},
+					&cli.StringFlag{
+						Name:  "template-ext",
+						Usage: "Output file `EXT` of custom main template.",
+					},
+				},
+			},
+			{
+				Name:    "CloudFormation",
+				Aliases: []string{"cfn"},
+				Usage:   "Export VPC, Subnets, Prefix Lists, Security Groups And Route53 To CloudFormation Template",
+				Action:  handelCloudFormation,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dst",
+						Usage: "Export Destination, Default Source.",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Output File Location.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "resource",
+						Usage: "Export `RESOURCE`: all, vpc, subnet, prefix-list, sg, route53 (Repeatable).",
+						Value: cli.NewStringSlice(exportResourceAll),
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: cfnFormatYAML,
+						Usage: "Template `FORMAT`: yaml, json.",
+					},
+				},
 			},
 			{
 				Name:    "VPC",
@@ -142,9 +362,65 @@ func getYamlConfig(configPath string) error {
 		return err
 	}
 
+	if yamlConfig.Setting.DryRun {
+		dryRun = true
+	}
+	concurrencyConfig = &yamlConfig.Setting.Concurrency
+	timeoutConfig = &yamlConfig.Setting.Timeout
+
+	if dryRun {
+		log.Print("DryRun Mode, No Changes Will Be Made.")
+	}
+
 	return nil
 }
 
+func sgFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "include-name",
+			Usage: "Only Security Group Name Match `PATTERN`, glob or re:REGEX (Repeatable).",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-name",
+			Usage: "Skip Security Group Name Match `PATTERN`, glob or re:REGEX (Repeatable).",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "Only Security Group Has Tag `KEY[=VALUE]`, all have to match (Repeatable).",
+		},
+		&cli.StringSliceFlag{
+			Name:  "vpc",
+			Usage: "Only Security Group In `VPCID` (Repeatable).",
+		},
+		&cli.StringSliceFlag{
+			Name:  "id",
+			Usage: "Only Security Group `ID` (Repeatable).",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-id",
+			Usage: "Skip Security Group `ID` (Repeatable).",
+		},
+	}
+}
+
+// newSGFilter merge config and flags.
+func newSGFilter(c *cli.Context) *SecurityGroupConfig {
+	filter := &SecurityGroupConfig{}
+	if yamlConfig != nil {
+		filter = &yamlConfig.Setting.SecurityGroup
+	}
+
+	filter.IncludeNames = append(filter.IncludeNames, c.StringSlice("include-name")...)
+	filter.ExcludeNames = append(filter.ExcludeNames, c.StringSlice("exclude-name")...)
+	filter.Tags = append(filter.Tags, c.StringSlice("tag")...)
+	filter.VPCIDs = append(filter.VPCIDs, c.StringSlice("vpc")...)
+	filter.IDs = append(filter.IDs, c.StringSlice("id")...)
+	filter.ExcludeIDs = append(filter.ExcludeIDs, c.StringSlice("exclude-id")...)
+
+	return filter
+}
+
 func handelSG(c *cli.Context) error {
 	err := getYamlConfig(c.String("config"))
 	if err != nil {
@@ -154,22 +430,26 @@ func handelSG(c *cli.Context) error {
 	updateMode = c.Bool("update")
 	sourceSGID = c.String("sid")
 
+	tfOption := newTerraformOption(c)
+	filter := newSGFilter(c)
+
 	switch {
 	case c.Bool("src-export"):
-		ExportSecurityGroupRule(&yamlConfig.Setting.Source, c.String("output"), c.Bool("terraform-export"), &yamlConfig.Setting.Tags)
+		tfOption.Rename = &filter.Rename
+		ExportSecurityGroupRule(&yamlConfig.Setting.Source, c.String("output"), c.Bool("terraform-export"), &yamlConfig.Setting.Tags, tfOption, filter)
 	case c.Bool("dst-export"):
-		ExportSecurityGroupRule(&yamlConfig.Setting.Destination, c.String("output"), c.Bool("terraform-export"), &yamlConfig.Setting.Tags)
+		ExportSecurityGroupRule(&yamlConfig.Setting.Destination, c.String("output"), c.Bool("terraform-export"), &yamlConfig.Setting.Tags, tfOption, filter.destination(&yamlConfig.Setting.Destination))
 	case c.Bool("src-restore"):
 		AlertRestoreMessage()
-		RestoreSecurityGroupRule(&yamlConfig.Setting.Source, c.String("file"))
+		RestoreSecurityGroupRule(&yamlConfig.Setting.Source, c.String("file"), c.Bool("restore-by-name"), filter)
 	case c.Bool("dst-restore"):
 		AlertRestoreMessage()
-		RestoreSecurityGroupRule(&yamlConfig.Setting.Destination, c.String("file"))
+		RestoreSecurityGroupRule(&yamlConfig.Setting.Destination, c.String("file"), c.Bool("restore-by-name"), filter)
 	case updateMode:
 		UpdateModeGo()
 		SecurityGroupSyncGO(&yamlConfig.Setting)
 	case c.Bool("DontTouchThisButton"):
-		CleanSecurityGroupRule(&yamlConfig.Setting.Destination)
+		CleanSecurityGroupRule(&yamlConfig.Setting.Destination, c.String("confirm-account"))
 	case c.Bool("diff"):
 		DiffSecurityGroup(&yamlConfig.Setting)
 	default:
@@ -180,12 +460,45 @@ func handelSG(c *cli.Context) error {
 	return nil
 }
 
+func handelSGDiff(c *cli.Context) error {
+	// compare snapshot files offline, without config.
+	if len(c.String("from")) > 0 && len(c.String("live")) == 0 {
+		DiffSnapshotFile(c.String("from"), c.String("to"), "", newSGFilter(c))
+		return nil
+	}
+
+	err := getYamlConfig(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	filter := newSGFilter(c)
+
+	if len(c.String("from")) == 0 {
+		DiffSecurityGroup(&yamlConfig.Setting)
+		return nil
+	}
+
+	DiffSnapshotFile(c.String("from"), c.String("to"), c.String("live"), filter)
+
+	return nil
+}
+
 func handelR53(c *cli.Context) error {
 	err := getYamlConfig(c.String("config"))
 	if err != nil {
 		return err
 	}
 
+	r53Config := &yamlConfig.Setting.Route53
+	r53Config.IncludeTypes = append(r53Config.IncludeTypes, c.StringSlice("include-type")...)
+	r53Config.ExcludeTypes = append(r53Config.ExcludeTypes, c.StringSlice("exclude-type")...)
+	r53Config.IncludeNames = append(r53Config.IncludeNames, c.StringSlice("include-name")...)
+	r53Config.ExcludeNames = append(r53Config.ExcludeNames, c.StringSlice("exclude-name")...)
+	if c.IsSet("zone-name") {
+		r53Config.ZoneName = c.String("zone-name")
+	}
+
 	cc := askForConfirmation("Do you really want to do it ??")
 
 	if !cc {
@@ -198,6 +511,112 @@ func handelR53(c *cli.Context) error {
 	return nil
 }
 
+func handelResolver(c *cli.Context) error {
+	err := getYamlConfig(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	cc := askForConfirmation("Do you really want to do it ??")
+
+	if !cc {
+		fmt.Println("Bye...")
+		os.Exit(0)
+	}
+
+	ResolverSyncGO(&yamlConfig.Setting)
+
+	return nil
+}
+
+func handelMigrate(c *cli.Context) error {
+	err := getYamlConfig(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	// flags merged into config SecurityGroup.
+	newSGFilter(c)
+
+	manifest := loadMigrateManifest(c.String("manifest"))
+	if len(c.StringSlice("resource")) > 0 {
+		manifest.Resources = c.StringSlice("resource")
+	}
+	if len(c.String("mapping-file")) > 0 {
+		manifest.MappingFile = c.String("mapping-file")
+	}
+
+	MigrateGO(&yamlConfig.Setting, manifest, c.Bool("skip-preflight"))
+
+	return nil
+}
+
+func handelPreflight(c *cli.Context) error {
+	err := getYamlConfig(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	// flags merged into config SecurityGroup.
+	newSGFilter(c)
+
+	PreflightGO(&yamlConfig.Setting, c.StringSlice("resource"), c.Bool("update"))
+
+	return nil
+}
+
+func handelTerraform(c *cli.Context) error {
+	err := getYamlConfig(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	option := newTerraformOption(c)
+
+	account := &yamlConfig.Setting.Source
+	option.Rename = &yamlConfig.Setting.SecurityGroup.Rename
+	if c.Bool("dst") {
+		account = &yamlConfig.Setting.Destination
+		option.Rename = nil
+	}
+
+	ExportTerraform(account, c.String("output"), c.StringSlice("resource"), &yamlConfig.Setting.Tags, option)
+
+	return nil
+}
+
+// newTerraformOption flags append / override config.yaml Template.
+func newTerraformOption(c *cli.Context) TerraformOption {
+	option := TerraformOption{
+		Import:    c.String("terraform-import"),
+		Style:     c.String("terraform-style"),
+		Templates: append(yamlConfig.Setting.Template.Paths, c.StringSlice("template")...),
+		Extension: yamlConfig.Setting.Template.Extension,
+	}
+
+	if len(c.String("template-ext")) > 0 {
+		option.Extension = c.String("template-ext")
+	}
+
+	return option
+}
+
+func handelCloudFormation(c *cli.Context) error {
+	err := getYamlConfig(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	account := &yamlConfig.Setting.Source
+	if c.Bool("dst") {
+		account = &yamlConfig.Setting.Destination
+	}
+
+	ExportCloudFormation(account, c.String("output"), c.StringSlice("resource"), c.String("format"), &yamlConfig.Setting.Tags)
+
+	return nil
+}
+
 func handelVPC(c *cli.Context) error {
 	err := getYamlConfig(c.String("config"))
 	if err != nil {
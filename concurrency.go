@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWorkers = 4
+	// ec2 mutating actions bucket refill about 5 per second.
+	defaultRate  = 5.0
+	defaultBurst = 10
+
+	throttleMaxRetries = 8
+	throttleBaseDelay  = 500 * time.Millisecond
+	throttleMaxDelay   = 20 * time.Second
+
+	serviceEC2      = "ec2"
+	serviceRoute53  = "route53"
+	serviceResolver = "route53resolver"
+	serviceLogs     = "logs"
+	serviceSTS      = "sts"
+	serviceQuotas   = "servicequotas"
+)
+
+var (
+	// flags, override config Concurrency.
+	workers int
+	apiRate float64
+
+	concurrencyConfig = &ConcurrencyConfig{}
+
+	limiterLock sync.Mutex
+	limiters    = make(map[string]*rateLimiter)
+)
+
+// rateLimiter token bucket, rate halved on throttling and slowly recovered on success.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	maxRate float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		maxRate: rate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// wait block until a token is available.
+func (l *rateLimiter) wait() {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		time.Sleep(delay)
+	}
+}
+
+func (l *rateLimiter) throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate /= 2
+	if min := l.maxRate / 16; l.rate < min {
+		l.rate = min
+	}
+	l.tokens = 0
+}
+
+func (l *rateLimiter) succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate < l.maxRate {
+		l.rate += l.maxRate / 20
+		if l.rate > l.maxRate {
+			l.rate = l.maxRate
+		}
+	}
+}
+
+// getWorkers flag, config, default.
+func getWorkers() int {
+	switch {
+	case workers > 0:
+		return workers
+	case concurrencyConfig.Workers > 0:
+		return concurrencyConfig.Workers
+	}
+	return defaultWorkers
+}
+
+// getLimiter rate of service by flag, config, default.
+func getLimiter(service string) *rateLimiter {
+	limiterLock.Lock()
+	defer limiterLock.Unlock()
+
+	if l, ok := limiters[service]; ok {
+		return l
+	}
+
+	rate := defaultRate
+	if r, ok := concurrencyConfig.Rate[service]; ok && r > 0 {
+		rate = r
+	}
+	if apiRate > 0 {
+		rate = apiRate
+	}
+
+	burst := defaultBurst
+	if concurrencyConfig.Burst > 0 {
+		burst = concurrencyConfig.Burst
+	}
+
+	limiters[service] = newRateLimiter(rate, burst)
+	return limiters[service]
+}
+
+// limitedCall rate limited call of service with call timeout, throttled, transient and timed out call
+// retried with exponential backoff and jitter, exit if cancelled before a call or on failed call.
+func limitedCall(service string, call func(ctx context.Context) error) error {
+	l := getLimiter(service)
+
+	var err error
+	for attempt := 0; attempt <= throttleMaxRetries; attempt++ {
+		exitIfCancelled()
+		l.wait()
+
+		ctx, cancel := context.WithTimeout(rootCtx, callTimeout(service))
+		err = call(ctx)
+		cancel()
+
+		// succeeded call returned to be journaled, cancel checked before next call.
+		if err != nil {
+			exitIfCancelled()
+		}
+
+		switch classifyError(err) {
+		case errThrottled:
+			l.throttled()
+		case errTransient:
+		default:
+			l.succeeded()
+			return err
+		}
+
+		delay := throttleBaseDelay << uint(attempt)
+		if delay > throttleMaxDelay {
+			delay = throttleMaxDelay
+		}
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+
+		log.Printf("%s %v, Retry %d/%d After %v.", service, errorSummary(err), attempt+1, throttleMaxRetries, delay)
+		if !sleepContext(delay) {
+			exitIfCancelled()
+		}
+	}
+
+	return err
+}
+
+// runParallel run jobs by bounded workers, wait all done, return errs of failed jobs.
+func runParallel(jobs []func() error) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	queue := make(chan func() error)
+
+	for i := 0; i < getWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				// cancelled, queued jobs not started.
+				if rootCtx.Err() != nil {
+					continue
+				}
+				if err := job(); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+
+	wg.Wait()
+	exitIfCancelled()
+
+	return errs
+}
+
+// exitOnErrors exit if any job failed, all errs printed.
+func exitOnErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+
+	for _, err := range errs[1:] {
+		log.Println(err)
+	}
+	exitErrorf("%v", errs[0])
+}
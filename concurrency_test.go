@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+)
+
+func TestLimitedCallRetries(t *testing.T) {
+	tests := []struct {
+		name  string
+		errs  []error
+		calls int
+		fail  bool
+	}{
+		{"throttled", []error{awserr.New("RequestLimitExceeded", "", nil), awserr.New("Throttling", "", nil)}, 3, false},
+		{"transient", []error{awserr.New("InternalError", "", nil)}, 2, false},
+		{"permanent", []error{awserr.New("InvalidVpcID.NotFound", "", nil)}, 1, true},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		err := limitedCall("test-"+tt.name, func(ctx context.Context) error {
+			calls++
+			if calls <= len(tt.errs) {
+				return tt.errs[calls-1]
+			}
+			return nil
+		})
+
+		if calls != tt.calls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, calls, tt.calls)
+		}
+		if (err != nil) != tt.fail {
+			t.Errorf("%s: err = %v, want fail %v", tt.name, err, tt.fail)
+		}
+		// rate of service halved on throttling only.
+		if l := getLimiter("test-" + tt.name); (l.rate < l.maxRate) != (tt.name == "throttled") {
+			t.Errorf("%s: rate = %v, max %v", tt.name, l.rate, l.maxRate)
+		}
+	}
+}
+
+func TestRunParallelErrors(t *testing.T) {
+	defer func(n int) { workers = n }(workers)
+	workers = 3
+
+	var (
+		mu  sync.Mutex
+		ran []int
+	)
+	jobs := []func() error{}
+	for i := 0; i < 10; i++ {
+		i := i
+		jobs = append(jobs, func() error {
+			mu.Lock()
+			ran = append(ran, i)
+			mu.Unlock()
+			if i%3 == 0 {
+				return fmt.Errorf("job %d failed", i)
+			}
+			return nil
+		})
+	}
+
+	errs := runParallel(jobs)
+
+	if len(ran) != 10 {
+		t.Errorf("jobs ran = %d, want 10", len(ran))
+	}
+
+	got := []string{}
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	sort.Strings(got)
+	want := []string{"job 0 failed", "job 3 failed", "job 6 failed", "job 9 failed"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("errs = %v, want %v", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -20,10 +21,139 @@ type Tag struct {
 
 // AWSAccount ...
 type AWSAccount struct {
-	Source      awsAuth `yaml:"Source"`
-	Destination awsAuth `yaml:"Destination"`
-	DryRun      bool    `yaml:"DryRun"`
-	Tags        []Tag   `yaml:"Tags"`
+	Source      awsAuth        `yaml:"Source"`
+	Destination awsAuth        `yaml:"Destination"`
+	DryRun      bool           `yaml:"DryRun"`
+	Tags        []Tag          `yaml:"Tags"`
+	Route53     Route53Config  `yaml:"Route53"`
+	Resolver    ResolverConfig `yaml:"Resolver"`
+	Template    TemplateConfig `yaml:"Template"`
+
+	SecurityGroup SecurityGroupConfig `yaml:"SecurityGroup"`
+	CIDR          CIDRMapConfig       `yaml:"CIDR"`
+	Quota         QuotaConfig         `yaml:"Quota"`
+	Concurrency   ConcurrencyConfig   `yaml:"Concurrency"`
+	Timeout       TimeoutConfig       `yaml:"Timeout"`
+}
+
+// ConcurrencyConfig worker pool and api rate limit, flags override.
+type ConcurrencyConfig struct {
+	Workers int `yaml:"Workers"`
+	// requests per second of service: ec2, route53, route53resolver.
+	Rate  map[string]float64 `yaml:"Rate"`
+	Burst int                `yaml:"Burst"`
+}
+
+// TimeoutConfig duration e.g. "30s", "10m".
+type TimeoutConfig struct {
+	// each api call attempt.
+	Call time.Duration `yaml:"Call"`
+	// call timeout of service: ec2, route53, route53resolver, logs, sts, servicequotas.
+	Services map[string]time.Duration `yaml:"Services"`
+	// wait resource available, e.g. resolver endpoint.
+	Wait time.Duration `yaml:"Wait"`
+}
+
+// QuotaConfig destination quotas of preflight, override Service Quotas.
+type QuotaConfig struct {
+	SecurityGroupsPerRegion int64 `yaml:"SecurityGroupsPerRegion"`
+	RulesPerSecurityGroup   int64 `yaml:"RulesPerSecurityGroup"`
+	SubnetsPerVPC           int64 `yaml:"SubnetsPerVPC"`
+	RecordsPerHostedZone    int64 `yaml:"RecordsPerHostedZone"`
+}
+
+// CIDRMapConfig translate source CIDR of rules to destination CIDR.
+type CIDRMapConfig struct {
+	// source cidr: destination cidr, same prefix length keeps host bits, first of most specific.
+	Map map[string]string `yaml:"Map"`
+	// map source VPC CIDR blocks to destination VPC by order, subnets by Name tag.
+	Auto       bool   `yaml:"Auto"`
+	ReportFile string `yaml:"ReportFile"`
+}
+
+// SecurityGroupConfig source security group selection, groups referenced by selected groups are included.
+type SecurityGroupConfig struct {
+	// glob pattern, or regex with "re:" prefix.
+	IncludeNames []string `yaml:"IncludeNames"`
+	ExcludeNames []string `yaml:"ExcludeNames"`
+	// Key=Value (value glob pattern) or Key, all have to match.
+	Tags       []string `yaml:"Tags"`
+	VPCIDs     []string `yaml:"VPCIDs"`
+	IDs        []string `yaml:"IDs"`
+	ExcludeIDs []string `yaml:"ExcludeIDs"`
+
+	// source VPCID: destination VPCID, default Destination VPCID.
+	VPCMap map[string]string `yaml:"VPCMap"`
+
+	Rename     SGRenameConfig    `yaml:"Rename"`
+	References SGReferenceConfig `yaml:"References"`
+}
+
+// SGReferenceConfig rule references to groups not migrated, in other accounts or peered VPCs.
+type SGReferenceConfig struct {
+	// source group id: destination group id, "USERID/GROUPID" for group in other account.
+	GroupMap map[string]string `yaml:"GroupMap"`
+	// source peering connection id: destination peering connection id.
+	PeeringMap map[string]string `yaml:"PeeringMap"`
+}
+
+// SGRenameConfig destination security group name, Map first, otherwise Replace then Prefix / Suffix.
+type SGRenameConfig struct {
+	// source name: destination name.
+	Map     map[string]string `yaml:"Map"`
+	Replace []RegexReplace    `yaml:"Replace"`
+	Prefix  string            `yaml:"Prefix"`
+	Suffix  string            `yaml:"Suffix"`
+
+	// group description, and rule descriptions if RuleDescriptions.
+	DescriptionReplace []RegexReplace `yaml:"DescriptionReplace"`
+	RuleDescriptions   bool           `yaml:"RuleDescriptions"`
+}
+
+// RegexReplace ...
+type RegexReplace struct {
+	Pattern string `yaml:"Pattern"`
+	Replace string `yaml:"Replace"`
+}
+
+// TemplateConfig ...
+type TemplateConfig struct {
+	// template files or directories (*.tmpl), override the embedded templates.
+	Paths []string `yaml:"Paths"`
+	// output file extension when custom "main" template defined.
+	Extension string `yaml:"Extension"`
+}
+
+// ResolverConfig ...
+type ResolverConfig struct {
+	// source id: destination id, default map by same cidr / name.
+	SubnetMap        map[string]string `yaml:"SubnetMap"`
+	SecurityGroupMap map[string]string `yaml:"SecurityGroupMap"`
+	VPCMap           map[string]string `yaml:"VPCMap"`
+}
+
+// Route53Config ...
+type Route53Config struct {
+	IncludeTypes []string `yaml:"IncludeTypes"`
+	ExcludeTypes []string `yaml:"ExcludeTypes"`
+	IncludeNames []string `yaml:"IncludeNames"`
+	ExcludeNames []string `yaml:"ExcludeNames"`
+	ZoneName     string   `yaml:"ZoneName"`
+
+	// key is record type, "*" for all type.
+	TTL map[string]TTLRule `yaml:"TTL"`
+	// key is record type (A, AAAA, CNAME, TXT), value is old: new.
+	Values     map[string]map[string]string `yaml:"Values"`
+	ReportFile string                       `yaml:"ReportFile"`
+
+	QueryLogGroupARN string `yaml:"QueryLogGroupARN"`
+	DNSSECKMSKeyARN  string `yaml:"DNSSECKMSKeyARN"`
+}
+
+// TTLRule ...
+type TTLRule struct {
+	Override int64 `yaml:"Override"`
+	Max      int64 `yaml:"Max"`
 }
 
 type awsAuth struct {
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// normalizeRule rule key without description, group and prefix list reference by name,
+// the same rule of different accounts has the same key.
+func (snapshot *Snapshot) normalizeRule(sg SnapshotSecurityGroup, rule SGRule) string {
+	source := rule.CidrIPv4
+	switch {
+	case len(rule.CidrIPv6) > 0:
+		source = rule.CidrIPv6
+	case rule.Self:
+		source = "self"
+	case len(rule.PrefixListID) > 0:
+		source = "pl:" + rule.PrefixListID
+		for _, pl := range snapshot.PrefixLists {
+			if pl.PrefixListID == rule.PrefixListID {
+				source = "pl:" + pl.Name
+				break
+			}
+		}
+	case len(rule.GroupID) > 0:
+		source = "sg:" + rule.GroupID
+		if rule.GroupID == sg.GroupID {
+			source = "self"
+			break
+		}
+		for _, g := range snapshot.SecurityGroups {
+			if g.GroupID == rule.GroupID {
+				source = "sg:" + g.GroupName
+				break
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s %s %d-%d %s", rule.Type, rule.Protocol, rule.FromPort, rule.ToPort, source)
+}
+
+// normalizeRules rule key: description.
+func (snapshot *Snapshot) normalizeRules(sg SnapshotSecurityGroup) map[string]string {
+	rules := make(map[string]string)
+	for _, rule := range sg.Rules {
+		rules[snapshot.normalizeRule(sg, rule)] = rule.Description
+	}
+	return rules
+}
+
+func (snapshot *Snapshot) groupNameMap() map[string]SnapshotSecurityGroup {
+	// stable order, key of duplicate names not depend on describe order.
+	sgs := make([]SnapshotSecurityGroup, len(snapshot.SecurityGroups))
+	copy(sgs, snapshot.SecurityGroups)
+	sort.Slice(sgs, func(i, j int) bool {
+		if sgs[i].VpcID != sgs[j].VpcID {
+			return sgs[i].VpcID < sgs[j].VpcID
+		}
+		if sgs[i].GroupName != sgs[j].GroupName {
+			return sgs[i].GroupName < sgs[j].GroupName
+		}
+		return sgs[i].GroupID < sgs[j].GroupID
+	})
+
+	groups := make(map[string]SnapshotSecurityGroup)
+	for _, sg := range sgs {
+		// same name in different vpc.
+		name := sg.GroupName
+		if _, ok := groups[name]; ok {
+			name = sg.GroupName + "(" + sg.VpcID + ")"
+		}
+		groups[name] = sg
+	}
+	return groups
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffSnapshots compare security groups by name, rule level, return true if all match.
+func diffSnapshots(from, to *Snapshot) bool {
+	fromGroups := from.groupNameMap()
+	toGroups := to.groupNameMap()
+
+	names := make(map[string]string)
+	for name := range fromGroups {
+		names[name] = ""
+	}
+	for name := range toGroups {
+		names[name] = ""
+	}
+
+	match := true
+
+	for _, name := range sortedKeys(names) {
+		fromSG, inFrom := fromGroups[name]
+		toSG, inTo := toGroups[name]
+
+		switch {
+		case !inTo:
+			log.Printf("Security Group Name: %s, Only In From (%s)", name, fromSG.GroupID)
+			match = false
+			continue
+		case !inFrom:
+			log.Printf("Security Group Name: %s, Only In To (%s)", name, toSG.GroupID)
+			match = false
+			continue
+		}
+
+		fromRules := from.normalizeRules(fromSG)
+		toRules := to.normalizeRules(toSG)
+
+		var changes []string
+		for _, key := range sortedKeys(fromRules) {
+			description, ok := toRules[key]
+			switch {
+			case !ok:
+				changes = append(changes, "- "+key)
+			case description != fromRules[key]:
+				changes = append(changes, fmt.Sprintf("~ %s, Description: %q -> %q", key, fromRules[key], description))
+			}
+		}
+		for _, key := range sortedKeys(toRules) {
+			if _, ok := fromRules[key]; !ok {
+				changes = append(changes, "+ "+key)
+			}
+		}
+
+		if len(changes) > 0 {
+			match = false
+			log.Printf("Security Group Name: %s, Rules Not Match:\n    %s", name, strings.Join(changes, "\n    "))
+		}
+	}
+
+	if match {
+		log.Print("Security Group All Match.")
+	}
+
+	return match
+}
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestNormalizeRule(t *testing.T) {
+	snapshot := &Snapshot{
+		SecurityGroups: []SnapshotSecurityGroup{
+			{GroupID: "sg-1", GroupName: "web"},
+			{GroupID: "sg-2", GroupName: "db"},
+		},
+		PrefixLists: []SnapshotPrefixList{
+			{PrefixListID: "pl-1", Name: "office"},
+		},
+	}
+	sg := snapshot.SecurityGroups[0]
+
+	tests := []struct {
+		rule SGRule
+		want string
+	}{
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "10.0.0.0/8", Description: "https"},
+			"ingress tcp 443-443 10.0.0.0/8"},
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv6: "::/0"},
+			"ingress tcp 443-443 ::/0"},
+		{SGRule{Type: "ingress", Protocol: "-1", Self: true},
+			"ingress -1 0-0 self"},
+		// self referenced by group id.
+		{SGRule{Type: "ingress", Protocol: "-1", GroupID: "sg-1"},
+			"ingress -1 0-0 self"},
+		{SGRule{Type: "egress", Protocol: "tcp", FromPort: 5432, ToPort: 5432, GroupID: "sg-2"},
+			"egress tcp 5432-5432 sg:db"},
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, GroupID: "123456789012/sg-9"},
+			"ingress tcp 22-22 sg:123456789012/sg-9"},
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, PrefixListID: "pl-1"},
+			"ingress tcp 22-22 pl:office"},
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, PrefixListID: "pl-9"},
+			"ingress tcp 22-22 pl:pl-9"},
+	}
+
+	for _, tt := range tests {
+		if got := snapshot.normalizeRule(sg, tt.rule); got != tt.want {
+			t.Errorf("normalizeRule(%+v) = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestGroupNameMapOrder(t *testing.T) {
+	a := SnapshotSecurityGroup{GroupID: "sg-2", GroupName: "web", VpcID: "vpc-b"}
+	b := SnapshotSecurityGroup{GroupID: "sg-1", GroupName: "web", VpcID: "vpc-a"}
+	c := SnapshotSecurityGroup{GroupID: "sg-3", GroupName: "db", VpcID: "vpc-a"}
+
+	for _, sgs := range [][]SnapshotSecurityGroup{{a, b, c}, {c, b, a}} {
+		groups := (&Snapshot{SecurityGroups: sgs}).groupNameMap()
+		if len(groups) != 3 {
+			t.Fatalf("groups = %+v, want 3", groups)
+		}
+		if groups["web"].GroupID != "sg-1" {
+			t.Errorf("web = %s, want sg-1", groups["web"].GroupID)
+		}
+		if groups["web(vpc-b)"].GroupID != "sg-2" {
+			t.Errorf("web(vpc-b) = %s, want sg-2", groups["web(vpc-b)"].GroupID)
+		}
+		if groups["db"].GroupID != "sg-3" {
+			t.Errorf("db = %s, want sg-3", groups["db"].GroupID)
+		}
+	}
+}
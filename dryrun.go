@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+const (
+	dryRunIDPrefix      = "dryrun-"
+	dryRunOperationCode = "DryRunOperation"
+)
+
+// changes would have been made, by order.
+var (
+	dryRunActions []string
+	dryRunLock    sync.Mutex
+)
+
+func dryRunRecord(format string, args ...interface{}) {
+	action := fmt.Sprintf(format, args...)
+
+	dryRunLock.Lock()
+	dryRunActions = append(dryRunActions, action)
+	dryRunLock.Unlock()
+
+	log.Printf("DryRun: Would %s", action)
+}
+
+// dryRunOK ec2 DryRunOperation response, the request would have succeeded.
+func dryRunOK(err error, format string, args ...interface{}) bool {
+	if errorCode(err) == dryRunOperationCode {
+		dryRunRecord(format, args...)
+		return true
+	}
+	return false
+}
+
+// dryRunSimulate api without DryRun parameter (route53, logs, resolver), or depend on resource
+// not created in dry run, return true if the call has to be skipped.
+func dryRunSimulate(format string, args ...interface{}) bool {
+	if !dryRun {
+		return false
+	}
+	dryRunRecord(format, args...)
+	return true
+}
+
+// dryRunID placeholder id of resource not created in dry run.
+func dryRunID(name string) *string {
+	return aws.String(dryRunIDPrefix + name)
+}
+
+func isDryRunID(id *string) bool {
+	return strings.HasPrefix(aws.StringValue(id), dryRunIDPrefix)
+}
+
+// hasDryRunID group or referenced group / prefix list not created in dry run,
+// ec2 DryRun validate ids, has to be simulated.
+func hasDryRunID(groupID *string, ippList []ec2.IpPermission) bool {
+	if isDryRunID(groupID) {
+		return true
+	}
+
+	for _, ipp := range ippList {
+		for _, ugp := range ipp.UserIdGroupPairs {
+			if isDryRunID(ugp.GroupId) {
+				return true
+			}
+		}
+		for _, pl := range ipp.PrefixListIds {
+			if isDryRunID(pl.PrefixListId) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// DryRunSummary printed after command, or before exit on error and cancellation.
+func DryRunSummary() {
+	if !dryRun {
+		return
+	}
+
+	dryRunLock.Lock()
+	defer dryRunLock.Unlock()
+
+	log.Printf("DryRun Summary, %d Changes Would Have Been Made:", len(dryRunActions))
+	for i, action := range dryRunActions {
+		fmt.Printf("%4d. %s\n", i+1, action)
+	}
+}
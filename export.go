@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+const (
+	exportResourceAll        = "all"
+	exportResourceVPC        = "vpc"
+	exportResourceSubnet     = "subnet"
+	exportResourceRouteTable = "route-table"
+	exportResourcePrefixList = "prefix-list"
+	exportResourceRoute53    = "route53"
+	exportResourceSG         = "sg"
+)
+
+// ExportResources resources of the account VPC, for terraform and cloudformation export.
+type ExportResources struct {
+	VPCs           []ec2.Vpc
+	Subnets        []ec2.Subnet
+	RouteTables    []ec2.RouteTable
+	PrefixLists    []*PerfixList
+	SecurityGroups []ec2.SecurityGroup
+	HostedZone     *route53.HostedZone
+	HostedZoneTags []ec2.Tag
+	// without zone apex NS and SOA
+	RecordSets []route53.ResourceRecordSet
+}
+
+func getExportResources(account *awsAuth, resources []string) *ExportResources {
+	res := &ExportResources{}
+
+	if resourceSelected(resources, exportResourceVPC) {
+		res.VPCs = getVPCsInfo(account)
+	}
+
+	if resourceSelected(resources, exportResourceSubnet) {
+		for _, subnet := range getSubnetsInfo(account) {
+			if aws.StringValue(subnet.VpcId) != account.VIPCID {
+				continue
+			}
+			res.Subnets = append(res.Subnets, subnet)
+		}
+	}
+
+	if resourceSelected(resources, exportResourceRouteTable) {
+		res.RouteTables = getRouteTables(account)
+	}
+
+	if resourceSelected(resources, exportResourcePrefixList) {
+		res.PrefixLists = getCustomerPrefixLists(account)
+	}
+
+	if resourceSelected(resources, exportResourceSG) {
+		for _, sg := range GetSGList(account) {
+			if aws.StringValue(sg.VpcId) != account.VIPCID {
+				continue
+			}
+			res.SecurityGroups = append(res.SecurityGroups, sg)
+		}
+	}
+
+	if resourceSelected(resources, exportResourceRoute53) && len(account.HostedZoneID) > 0 {
+		recordList, zone := getDNSRecordList(account)
+		if zone == nil {
+			log.Fatalf("Unable to get hosted zone %q", account.HostedZoneID)
+		}
+
+		res.HostedZone = zone
+		res.HostedZoneTags = route53EC2Tags(getHostedZoneTags(account, aws.StringValue(zone.Id)))
+
+		zoneName := normalizeDNSName(aws.StringValue(zone.Name))
+		for _, rr := range recordList.ResourceRecordSets {
+			// zone apex ns and soa managed by hosted zone.
+			if (rr.Type == route53.RRTypeNs || rr.Type == route53.RRTypeSoa) && normalizeDNSName(aws.StringValue(rr.Name)) == zoneName {
+				continue
+			}
+			res.RecordSets = append(res.RecordSets, rr)
+		}
+	}
+
+	return res
+}
+
+func resourceSelected(resources []string, resource string) bool {
+	if len(resources) == 0 {
+		return true
+	}
+	for _, r := range resources {
+		if r == exportResourceAll || r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func getRouteTables(account *awsAuth) []ec2.RouteTable {
+	svc := newSVC(account)
+
+	var result *ec2.DescribeRouteTablesResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeRouteTablesRequest(&ec2.DescribeRouteTablesInput{
+			Filters: []ec2.Filter{
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []string{account.VIPCID},
+				},
+			},
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return result.RouteTables
+}
+
+// getCustomerPrefixLists list prefix lists owner by this account, without aws managed.
+func getCustomerPrefixLists(account *awsAuth) []*PerfixList {
+	svc := newSVC(account)
+
+	var result *ec2.DescribeManagedPrefixListsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeManagedPrefixListsRequest(&ec2.DescribeManagedPrefixListsInput{}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var plists []*PerfixList
+	for _, pl := range result.PrefixLists {
+		if aws.StringValue(pl.OwnerId) == "AWS" {
+			continue
+		}
+
+		var entries *ec2.GetManagedPrefixListEntriesResponse
+		err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+			entries, err = svc.GetManagedPrefixListEntriesRequest(&ec2.GetManagedPrefixListEntriesInput{
+				PrefixListId: pl.PrefixListId,
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		plists = append(plists, &PerfixList{
+			OldPerfixListID:   pl.PrefixListId,
+			ManagedPrefixList: pl,
+			PrefixListEntry:   entries.Entries,
+		})
+	}
+
+	return plists
+}
+
+// tagName return value of tag Name.
+func tagName(tags []ec2.Tag) string {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == "Name" {
+			return aws.StringValue(t.Value)
+		}
+	}
+	return ""
+}
+
+// route53EC2Tags route53 tags as ec2 tags, rendered and merged the same way.
+func route53EC2Tags(tags []route53.Tag) []ec2.Tag {
+	ec2Tags := []ec2.Tag{}
+	for _, t := range tags {
+		ec2Tags = append(ec2Tags, ec2.Tag{Key: t.Key, Value: t.Value})
+	}
+	return ec2Tags
+}
+
+// mergeTags resource tags (skip aws: reserved) and config tags.
+func mergeTags(tags []ec2.Tag, configTags *[]Tag) []Tag {
+	newTags := []Tag{}
+	keys := make(map[string]int)
+
+	add := func(key, value string) {
+		if i, ok := keys[key]; ok {
+			newTags[i].Value = value
+			return
+		}
+		keys[key] = len(newTags)
+		newTags = append(newTags, Tag{Key: key, Value: value})
+	}
+
+	add("CreateAt", time.Now().String())
+	for _, t := range tags {
+		if strings.HasPrefix(aws.StringValue(t.Key), "aws:") {
+			continue
+		}
+		add(aws.StringValue(t.Key), aws.StringValue(t.Value))
+	}
+	if configTags != nil {
+		for _, t := range *configTags {
+			add(t.Key, t.Value)
+		}
+	}
+
+	return newTags
+}
+
+// SGRule one source of security group rule.
+type SGRule struct {
+	Type         string `json:"Type"`
+	FromPort     int64  `json:"FromPort"`
+	ToPort       int64  `json:"ToPort"`
+	Protocol     string `json:"Protocol"`
+	CidrIPv4     string `json:"CidrIPv4,omitempty"`
+	CidrIPv6     string `json:"CidrIPv6,omitempty"`
+	PrefixListID string `json:"PrefixListID,omitempty"`
+	// USERID/GROUPID if group in other account.
+	GroupID     string `json:"GroupID,omitempty"`
+	Self        bool   `json:"Self,omitempty"`
+	Description string `json:"Description,omitempty"`
+}
+
+func flattenIPPermissions(sg ec2.SecurityGroup, ruleType string, ippList []ec2.IpPermission) []SGRule {
+	var rules []SGRule
+
+	for _, ipp := range ippList {
+		base := SGRule{
+			Type:     ruleType,
+			FromPort: aws.Int64Value(ipp.FromPort),
+			ToPort:   aws.Int64Value(ipp.ToPort),
+			Protocol: aws.StringValue(ipp.IpProtocol),
+		}
+		if len(base.Protocol) == 0 {
+			base.Protocol = "-1"
+		}
+		// all traffic, port must be 0.
+		if base.Protocol == "-1" {
+			base.FromPort, base.ToPort = 0, 0
+		}
+
+		for _, ipr := range ipp.IpRanges {
+			rule := base
+			rule.CidrIPv4 = aws.StringValue(ipr.CidrIp)
+			rule.Description = aws.StringValue(ipr.Description)
+			rules = append(rules, rule)
+		}
+
+		for _, ipr := range ipp.Ipv6Ranges {
+			rule := base
+			rule.CidrIPv6 = aws.StringValue(ipr.CidrIpv6)
+			rule.Description = aws.StringValue(ipr.Description)
+			rules = append(rules, rule)
+		}
+
+		for _, pl := range ipp.PrefixListIds {
+			rule := base
+			rule.PrefixListID = aws.StringValue(pl.PrefixListId)
+			rule.Description = aws.StringValue(pl.Description)
+			rules = append(rules, rule)
+		}
+
+		for _, ugp := range ipp.UserIdGroupPairs {
+			rule := base
+			rule.Description = aws.StringValue(ugp.Description)
+
+			switch {
+			case aws.StringValue(ugp.GroupId) == aws.StringValue(sg.GroupId):
+				rule.Self = true
+			case ugp.UserId != nil && aws.StringValue(ugp.UserId) != aws.StringValue(sg.OwnerId):
+				// group in other account.
+				rule.GroupID = aws.StringValue(ugp.UserId) + "/" + aws.StringValue(ugp.GroupId)
+			default:
+				rule.GroupID = aws.StringValue(ugp.GroupId)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// flattenSGRules split security group rules to one source per rule.
+func flattenSGRules(sg ec2.SecurityGroup) []SGRule {
+	rules := flattenIPPermissions(sg, "ingress", sg.IpPermissions)
+	return append(rules, flattenIPPermissions(sg, "egress", sg.IpPermissionsEgress)...)
+}
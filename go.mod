@@ -1,9 +1,10 @@
 module github.com/kyos0109/go-aws-migrate
 
-go 1.15
+go 1.16
 
 require (
 	github.com/aws/aws-sdk-go-v2 v0.24.0
 	github.com/urfave/cli/v2 v2.2.0
+	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed
 	gopkg.in/yaml.v2 v2.3.0
 )
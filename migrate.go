@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	migrateResourceDHCP     = "dhcp"
+	migrateResourceIGW      = "igw"
+	migrateResourceNACL     = "nacl"
+	migrateResourceEndpoint = "endpoint"
+	migrateResourceResolver = "resolver"
+)
+
+// MigrateManifest ...
+type MigrateManifest struct {
+	// resource types to migrate, run by dependency order.
+	Resources []string `yaml:"Resources"`
+	// Optional, source id: destination id of every step, json.
+	MappingFile string `yaml:"MappingFile"`
+}
+
+type migrateStep struct {
+	resource string
+	// run after these resources, if they are migrated too.
+	deps []string
+	// nil if not supported yet.
+	run func(m *migration)
+}
+
+type migration struct {
+	awsAccount *AWSAccount
+	// resource type: source id: destination id
+	mappings map[string]map[string]string
+}
+
+// migrateSteps dependency graph, by table order if no dependency between.
+func migrateSteps() []migrateStep {
+	return []migrateStep{
+		{resource: exportResourceVPC, run: (*migration).migrateVPC},
+		{resource: migrateResourceDHCP, deps: []string{exportResourceVPC}, run: (*migration).migrateDHCP},
+		{resource: migrateResourceIGW, deps: []string{exportResourceVPC}, run: (*migration).migrateIGW},
+		{resource: exportResourceSubnet, deps: []string{exportResourceVPC, migrateResourceDHCP, migrateResourceIGW}, run: (*migration).migrateSubnets},
+		{resource: exportResourceRouteTable, deps: []string{exportResourceSubnet, migrateResourceIGW}, run: (*migration).migrateRouteTables},
+		{resource: migrateResourceNACL, deps: []string{exportResourceSubnet}, run: (*migration).migrateNACLs},
+		{resource: exportResourcePrefixList, deps: []string{exportResourceRouteTable, migrateResourceNACL}, run: (*migration).migratePrefixLists},
+		{resource: exportResourceSG, deps: []string{exportResourceVPC, exportResourceRouteTable, migrateResourceNACL, exportResourcePrefixList}, run: (*migration).migrateSecurityGroups},
+		{resource: migrateResourceEndpoint, deps: []string{exportResourceSubnet, exportResourceRouteTable, exportResourceSG}, run: (*migration).migrateEndpoints},
+		{resource: migrateResourceResolver, deps: []string{exportResourceSubnet, exportResourceSG, migrateResourceEndpoint}, run: (*migration).migrateResolver},
+		{resource: exportResourceRoute53, deps: []string{exportResourceVPC, migrateResourceEndpoint, migrateResourceResolver}, run: (*migration).migrateRoute53},
+	}
+}
+
+func loadMigrateManifest(filePath string) *MigrateManifest {
+	manifest := &MigrateManifest{}
+	if len(filePath) == 0 {
+		return manifest
+	}
+
+	buff, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = yaml.Unmarshal(buff, manifest)
+	if err != nil {
+		log.Fatalf("Manifest %s error, %v", filePath, err)
+	}
+
+	return manifest
+}
+
+// planMigrate topological order of selected resources, exit if unknown or not supported.
+func planMigrate(resources []string) []migrateStep {
+	steps := migrateSteps()
+
+	selected := make(map[string]bool)
+	for _, r := range resources {
+		if r == exportResourceAll {
+			for _, step := range steps {
+				if step.run != nil {
+					selected[step.resource] = true
+				}
+			}
+			continue
+		}
+		selected[r] = true
+	}
+
+	known := make(map[string]migrateStep)
+	for _, step := range steps {
+		known[step.resource] = step
+	}
+
+	for r := range selected {
+		step, ok := known[r]
+		switch {
+		case !ok:
+			exitErrorf("Unknown migrate resource: %s", r)
+		case step.run == nil:
+			exitErrorf("Migrate resource %s not supported yet.", r)
+		}
+	}
+
+	if selected[exportResourcePrefixList] && !selected[exportResourceSG] {
+		exitErrorf("Prefix lists are migrated with security groups, add %s.", exportResourceSG)
+	}
+
+	done := make(map[string]bool)
+	plan := []migrateStep{}
+
+	for len(plan) < len(selected) {
+		progress := false
+
+		for _, step := range steps {
+			if !selected[step.resource] || done[step.resource] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range step.deps {
+				if selected[dep] && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			plan = append(plan, step)
+			done[step.resource] = true
+			progress = true
+		}
+
+		if !progress {
+			exitErrorf("Migrate resources dependency cycle.")
+		}
+	}
+
+	return plan
+}
+
+func (m *migration) record(resource string, srcID string, dstID string) {
+	if m.mappings[resource] == nil {
+		m.mappings[resource] = make(map[string]string)
+	}
+	m.mappings[resource][srcID] = dstID
+}
+
+func (m *migration) requireDestinationVPC(resource string) {
+	if len(m.awsAccount.Destination.VIPCID) == 0 {
+		exitErrorf("Migrate %s needs Destination VPCID, set it or add %s to manifest.", resource, exportResourceVPC)
+	}
+}
+
+func (m *migration) requireSourceVPC(resource string) {
+	if len(m.awsAccount.Source.VIPCID) == 0 {
+		exitErrorf("Migrate %s needs Source VPCID.", resource)
+	}
+}
+
+func (m *migration) migrateVPC() {
+	srcVPCID := m.awsAccount.Source.VIPCID
+
+	VPCSyncGO(m.awsAccount)
+
+	m.record(exportResourceVPC, srcVPCID, m.awsAccount.Destination.VIPCID)
+}
+
+// migrateSubnets subnet mappings feed resolver.
+func (m *migration) migrateSubnets() {
+	m.requireDestinationVPC(exportResourceSubnet)
+
+	resolverConfig := &m.awsAccount.Resolver
+	if resolverConfig.SubnetMap == nil {
+		resolverConfig.SubnetMap = make(map[string]string)
+	}
+
+	for srcID, dstID := range SubnetSyncGO(m.awsAccount) {
+		m.record(exportResourceSubnet, srcID, dstID)
+		if _, ok := resolverConfig.SubnetMap[srcID]; !ok {
+			resolverConfig.SubnetMap[srcID] = dstID
+		}
+	}
+}
+
+// migrateNetwork run sync of network resource, mappings recorded.
+func (m *migration) migrateNetwork(resource string, sync func(netSync *NetworkSync) map[string]string) {
+	m.requireSourceVPC(resource)
+	m.requireDestinationVPC(resource)
+
+	for srcID, dstID := range sync(newNetworkSync(m.awsAccount, m.mappings)) {
+		m.record(resource, srcID, dstID)
+	}
+}
+
+func (m *migration) migrateDHCP() {
+	m.migrateNetwork(migrateResourceDHCP, (*NetworkSync).SyncDHCPOptions)
+}
+
+func (m *migration) migrateIGW() {
+	m.migrateNetwork(migrateResourceIGW, (*NetworkSync).SyncInternetGateways)
+}
+
+// migrateRouteTables route table mappings feed endpoints.
+func (m *migration) migrateRouteTables() {
+	m.migrateNetwork(exportResourceRouteTable, (*NetworkSync).SyncRouteTables)
+}
+
+// migrateNACLs entries CIDR translated.
+func (m *migration) migrateNACLs() {
+	awsAccount := m.awsAccount
+	ct := newCIDRTranslator(&awsAccount.CIDR, &awsAccount.Source, &awsAccount.Destination,
+		awsAccount.SecurityGroup.VPCIDs, awsAccount.SecurityGroup.VPCMap)
+
+	m.migrateNetwork(migrateResourceNACL, func(netSync *NetworkSync) map[string]string {
+		return netSync.SyncNetworkACLs(ct)
+	})
+	ct.writeReport()
+}
+
+func (m *migration) migrateEndpoints() {
+	m.migrateNetwork(migrateResourceEndpoint, (*NetworkSync).SyncVPCEndpoints)
+}
+
+func (m *migration) migratePrefixLists() {
+	log.Print("Prefix Lists Migrated With Security Groups.")
+}
+
+// migrateSecurityGroups create mode, group mappings feed resolver.
+func (m *migration) migrateSecurityGroups() {
+	m.requireDestinationVPC(exportResourceSG)
+
+	updateMode = false
+	SecurityGroupSyncGO(m.awsAccount)
+
+	resolverConfig := &m.awsAccount.Resolver
+	if resolverConfig.SecurityGroupMap == nil {
+		resolverConfig.SecurityGroupMap = make(map[string]string)
+	}
+
+	for srcID, dstID := range sgIDMappings() {
+		m.record(exportResourceSG, srcID, dstID)
+		if _, ok := resolverConfig.SecurityGroupMap[srcID]; !ok {
+			resolverConfig.SecurityGroupMap[srcID] = dstID
+		}
+	}
+}
+
+func (m *migration) migrateResolver() {
+	m.requireDestinationVPC(migrateResourceResolver)
+
+	ResolverSyncGO(m.awsAccount)
+}
+
+func (m *migration) migrateRoute53() {
+	if len(m.awsAccount.Source.HostedZoneID) == 0 {
+		exitErrorf("Migrate %s needs Source HostedZoneID.", exportResourceRoute53)
+	}
+	m.requireDestinationVPC(exportResourceRoute53)
+
+	Route53SyncGO(m.awsAccount)
+
+	m.record(exportResourceRoute53, m.awsAccount.Source.HostedZoneID, m.awsAccount.Destination.HostedZoneID)
+}
+
+func (m *migration) writeMappings(filePath string) {
+	for _, resource := range sortedMappingKeys(m.mappings) {
+		for srcID, dstID := range m.mappings[resource] {
+			log.Printf("Migrate Mapping %s: %s -> %s", resource, srcID, dstID)
+		}
+	}
+
+	if len(filePath) == 0 {
+		return
+	}
+
+	buff, err := json.MarshalIndent(m.mappings, "", "  ")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = ioutil.WriteFile(filePath, buff, 0644)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Output Migrate Mapping: %s", filePath)
+}
+
+func sortedMappingKeys(m map[string]map[string]string) []string {
+	keys := make(map[string]string)
+	for k := range m {
+		keys[k] = ""
+	}
+	return sortedKeys(keys)
+}
+
+// MigrateGO run manifest resources by dependency order, each step ID mappings feed the next,
+// preflight checks first unless skipPreflight.
+func MigrateGO(awsAccount *AWSAccount, manifest *MigrateManifest, skipPreflight bool) {
+	if len(manifest.Resources) == 0 {
+		exitErrorf("Migrate needs resources, by manifest Resources or --resource.")
+	}
+
+	plan := planMigrate(manifest.Resources)
+
+	names := []string{}
+	for _, step := range plan {
+		names = append(names, step.resource)
+	}
+	log.Printf("Migrate Plan: %s", strings.Join(names, " -> "))
+
+	if !skipPreflight {
+		checks := []string{}
+		for _, r := range names {
+			switch r {
+			case exportResourceSubnet, exportResourceSG, exportResourceRoute53:
+				checks = append(checks, r)
+			}
+		}
+		if len(checks) > 0 {
+			PreflightGO(awsAccount, checks, false)
+		}
+	}
+
+	if !askForConfirmation("Do you really want to do it ??") {
+		log.Print("Bye...")
+		return
+	}
+
+	m := &migration{
+		awsAccount: awsAccount,
+		mappings:   make(map[string]map[string]string),
+	}
+
+	completed := []string{}
+
+	// cancelled, mappings of completed steps still written.
+	onCancel(func() {
+		log.Printf("Migrate Cancelled, Completed Steps: %v", completed)
+		m.writeMappings(manifest.MappingFile)
+	})
+
+	for i, step := range plan {
+		exitIfCancelled()
+
+		log.Printf("Migrate Step %d/%d: %s", i+1, len(plan), step.resource)
+		step.run(m)
+		completed = append(completed, step.resource)
+	}
+
+	m.writeMappings(manifest.MappingFile)
+
+	log.Print("Migrate Done.")
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanMigrate(t *testing.T) {
+	tests := []struct {
+		resources []string
+		want      []string
+	}{
+		{[]string{exportResourceAll}, []string{
+			"vpc", "dhcp", "igw", "subnet", "route-table", "nacl", "prefix-list", "sg", "endpoint", "resolver", "route53",
+		}},
+		{[]string{"route53", "endpoint", "vpc"}, []string{"vpc", "endpoint", "route53"}},
+		{[]string{"nacl", "route-table", "subnet"}, []string{"subnet", "route-table", "nacl"}},
+		{[]string{"sg", "prefix-list", "igw"}, []string{"igw", "prefix-list", "sg"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, step := range planMigrate(tt.resources) {
+			got = append(got, step.resource)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("planMigrate(%v) = %v, want %v", tt.resources, got, tt.want)
+		}
+	}
+}
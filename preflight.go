@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+)
+
+// service quotas, default value if Service Quotas unavailable.
+const (
+	quotaServiceVPC     = "vpc"
+	quotaServiceRoute53 = "route53"
+
+	quotaCodeSGPerRegion   = "L-E79EC296"
+	quotaCodeRulesPerSG    = "L-0EA8095F"
+	quotaCodeSubnetsPerVPC = "L-407747CB"
+	quotaCodeRecordsByZone = "L-E209CC9F"
+
+	quotaDefaultSGPerRegion   = 2500
+	quotaDefaultRulesPerSG    = 60
+	quotaDefaultSubnetsPerVPC = 200
+	quotaDefaultRecordsByZone = 10000
+
+	// route53 is global, quotas in us-east-1.
+	route53QuotaRegion = "us-east-1"
+)
+
+// PreflightCheck ...
+type PreflightCheck struct {
+	Name     string
+	Required int64
+	Limit    int64
+	// config, service-quotas, aws-default, default, hosted-zone-limit
+	LimitSource string
+	Message     string
+}
+
+func (check PreflightCheck) ok() bool {
+	return len(check.Message) == 0 && check.Required <= check.Limit
+}
+
+type preflight struct {
+	awsAccount *AWSAccount
+	checks     []PreflightCheck
+}
+
+// getQuota configured value first, then applied quota, aws default quota, built-in default.
+func getQuota(account *awsAuth, serviceCode string, quotaCode string, configured int64, defaultValue int64) (int64, string) {
+	if configured > 0 {
+		return configured, "config"
+	}
+
+	svc := servicequotas.New(newAWSConfig(account))
+
+	var result *servicequotas.GetServiceQuotaResponse
+	err := limitedCall(serviceQuotas, func(ctx context.Context) (err error) {
+		result, err = svc.GetServiceQuotaRequest(&servicequotas.GetServiceQuotaInput{
+			ServiceCode: aws.String(serviceCode),
+			QuotaCode:   aws.String(quotaCode),
+		}).Send(ctx)
+		return err
+	})
+	if err == nil && result.Quota != nil && result.Quota.Value != nil {
+		return int64(*result.Quota.Value), "service-quotas"
+	}
+
+	var defResult *servicequotas.GetAWSDefaultServiceQuotaResponse
+	defErr := limitedCall(serviceQuotas, func(ctx context.Context) (err error) {
+		defResult, err = svc.GetAWSDefaultServiceQuotaRequest(&servicequotas.GetAWSDefaultServiceQuotaInput{
+			ServiceCode: aws.String(serviceCode),
+			QuotaCode:   aws.String(quotaCode),
+		}).Send(ctx)
+		return err
+	})
+	if defErr == nil && defResult.Quota != nil && defResult.Quota.Value != nil {
+		return int64(*defResult.Quota.Value), "aws-default"
+	}
+
+	log.Printf("Get Service Quota %s/%s Error, Use Default %d, %v", serviceCode, quotaCode, defaultValue, err)
+	return defaultValue, "default"
+}
+
+func (pf *preflight) add(check PreflightCheck) {
+	pf.checks = append(pf.checks, check)
+}
+
+// checkSecurityGroups groups per region, rules per group (ipv4 / ipv6 separately, prefix list counts
+// its max entries), and name conflicts in create mode.
+func (pf *preflight) checkSecurityGroups(update bool) {
+	src, dst := &pf.awsAccount.Source, &pf.awsAccount.Destination
+	sgConfig := &pf.awsAccount.SecurityGroup
+
+	sgList := filterSGList(getSourceSGList(pf.awsAccount), sgConfig)
+	sgList = sgConfig.Rename.renameSGList(sgList)
+	snapshot := newSnapshot(src, sgList)
+
+	dstSGList := GetSGListByVPC(dst)
+	dstNames := make(map[sgKey]bool)
+	for _, sg := range dstSGList {
+		dstNames[sgKey{VpcID: aws.StringValue(sg.VpcId), Name: aws.StringValue(sg.GroupName)}] = true
+	}
+
+	awssync := &AWSSync{sgConfig: sgConfig}
+
+	var newGroups int64
+	for _, sg := range snapshot.SecurityGroups {
+		key := sgKey{VpcID: awssync.destinationVPC(dst, sg.VpcID), Name: sg.GroupName}
+		if dstNames[key] {
+			if !update && sg.GroupName != sgDefaultName {
+				pf.add(PreflightCheck{
+					Name:    fmt.Sprintf("Security Group %s", sg.GroupName),
+					Message: fmt.Sprintf("already exists in VPC %s, create fails, use update mode", key.VpcID),
+				})
+			}
+			continue
+		}
+		if sg.GroupName != sgDefaultName {
+			newGroups++
+		}
+	}
+
+	limit, limitSource := getQuota(dst, quotaServiceVPC, quotaCodeSGPerRegion, pf.awsAccount.Quota.SecurityGroupsPerRegion, quotaDefaultSGPerRegion)
+	pf.add(PreflightCheck{
+		Name:        fmt.Sprintf("Security Groups Per Region (%d existing + %d new)", len(dstSGList), newGroups),
+		Required:    int64(len(dstSGList)) + newGroups,
+		Limit:       limit,
+		LimitSource: limitSource,
+	})
+
+	maxEntries := make(map[string]int64)
+	ipv6PrefixList := make(map[string]bool)
+	for _, pl := range snapshot.PrefixLists {
+		maxEntries[pl.PrefixListID] = pl.MaxEntries
+		ipv6PrefixList[pl.PrefixListID] = pl.AddressFamily == "IPv6"
+	}
+
+	limit, limitSource = getQuota(dst, quotaServiceVPC, quotaCodeRulesPerSG, pf.awsAccount.Quota.RulesPerSecurityGroup, quotaDefaultRulesPerSG)
+	for _, sg := range snapshot.SecurityGroups {
+		counts := make(map[string]int64)
+		for _, rule := range sg.Rules {
+			family := "IPv4"
+			weight := int64(1)
+
+			switch {
+			case len(rule.CidrIPv6) > 0:
+				family = "IPv6"
+			case len(rule.PrefixListID) > 0:
+				if ipv6PrefixList[rule.PrefixListID] {
+					family = "IPv6"
+				}
+				if maxEntries[rule.PrefixListID] > 0 {
+					weight = maxEntries[rule.PrefixListID]
+				}
+			}
+
+			counts[rule.Type+" "+family] += weight
+		}
+
+		for _, k := range sortedCountKeys(counts) {
+			pf.add(PreflightCheck{
+				Name:        fmt.Sprintf("Rules Per Security Group %s (%s)", sg.GroupName, k),
+				Required:    counts[k],
+				Limit:       limit,
+				LimitSource: limitSource,
+			})
+		}
+	}
+}
+
+func sortedCountKeys(m map[string]int64) []string {
+	keys := make(map[string]string)
+	for k := range m {
+		keys[k] = ""
+	}
+	return sortedKeys(keys)
+}
+
+// checkSubnets source subnets and existing destination subnets in destination VPC.
+func (pf *preflight) checkSubnets() {
+	src, dst := &pf.awsAccount.Source, &pf.awsAccount.Destination
+
+	var srcCount, dstCount int64
+	for _, subnet := range getSubnetsInfo(src) {
+		if aws.StringValue(subnet.VpcId) == src.VIPCID {
+			srcCount++
+		}
+	}
+	for _, subnet := range getSubnetsInfo(dst) {
+		if aws.StringValue(subnet.VpcId) == dst.VIPCID {
+			dstCount++
+		}
+	}
+
+	limit, limitSource := getQuota(dst, quotaServiceVPC, quotaCodeSubnetsPerVPC, pf.awsAccount.Quota.SubnetsPerVPC, quotaDefaultSubnetsPerVPC)
+	pf.add(PreflightCheck{
+		Name:        fmt.Sprintf("Subnets Per VPC %s (%d existing + %d new)", dst.VIPCID, dstCount, srcCount),
+		Required:    dstCount + srcCount,
+		Limit:       limit,
+		LimitSource: limitSource,
+	})
+}
+
+// checkRecords filtered source records, records already in destination zone are upserted.
+func (pf *preflight) checkRecords() {
+	src, dst := &pf.awsAccount.Source, &pf.awsAccount.Destination
+	if len(src.HostedZoneID) == 0 {
+		return
+	}
+
+	var r53sync route53Sync
+	r53sync.config = &pf.awsAccount.Route53
+	r53sync.srcRecordListRes, r53sync.srcHostedZone = getDNSRecordList(src)
+	if r53sync.srcHostedZone == nil {
+		log.Fatalf("Unable to get hosted zone %q", src.HostedZoneID)
+	}
+	r53sync.removeSrcDefaultRecord()
+	r53sync.filterRecords()
+
+	newRecords := int64(len(r53sync.srcRecordListRes.ResourceRecordSets))
+
+	if len(dst.HostedZoneID) == 0 {
+		// new zone has apex NS and SOA.
+		limit, limitSource := getQuota(&awsAuth{AccessKey: dst.AccessKey, SecretKey: dst.SecretKey, Region: route53QuotaRegion},
+			quotaServiceRoute53, quotaCodeRecordsByZone, pf.awsAccount.Quota.RecordsPerHostedZone, quotaDefaultRecordsByZone)
+		pf.add(PreflightCheck{
+			Name:        fmt.Sprintf("Records Per Hosted Zone (new zone, 2 + %d new)", newRecords),
+			Required:    2 + newRecords,
+			Limit:       limit,
+			LimitSource: limitSource,
+		})
+		return
+	}
+
+	dstRecordList, _ := getDNSRecordList(dst)
+	if dstRecordList == nil {
+		log.Fatalf("Unable to get hosted zone %q", dst.HostedZoneID)
+	}
+
+	existing := make(map[string]bool)
+	for _, rr := range dstRecordList.ResourceRecordSets {
+		existing[aws.StringValue(rr.Name)+string(rr.Type)+aws.StringValue(rr.SetIdentifier)] = true
+	}
+	for _, rr := range r53sync.srcRecordListRes.ResourceRecordSets {
+		if existing[aws.StringValue(rr.Name)+string(rr.Type)+aws.StringValue(rr.SetIdentifier)] {
+			newRecords--
+		}
+	}
+
+	svc := newRoute53SVC(dst)
+	var result *route53.GetHostedZoneLimitResponse
+	err := limitedCall(serviceRoute53, func(ctx context.Context) (err error) {
+		result, err = svc.GetHostedZoneLimitRequest(&route53.GetHostedZoneLimitInput{
+			HostedZoneId: aws.String(dst.HostedZoneID),
+			Type:         route53.HostedZoneLimitTypeMaxRrsetsByZone,
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	limit, limitSource := aws.Int64Value(result.Limit.Value), "hosted-zone-limit"
+	if pf.awsAccount.Quota.RecordsPerHostedZone > 0 {
+		limit, limitSource = pf.awsAccount.Quota.RecordsPerHostedZone, "config"
+	}
+
+	pf.add(PreflightCheck{
+		Name:        fmt.Sprintf("Records Per Hosted Zone %s (%d existing + %d new)", dst.HostedZoneID, aws.Int64Value(result.Count), newRecords),
+		Required:    aws.Int64Value(result.Count) + newRecords,
+		Limit:       limit,
+		LimitSource: limitSource,
+	})
+}
+
+func (pf *preflight) report() int {
+	violations := 0
+
+	for _, check := range pf.checks {
+		status := "OK"
+		if !check.ok() {
+			status = "VIOLATION"
+			violations++
+		}
+
+		if len(check.Message) > 0 {
+			fmt.Printf("%-10s %s, %s\n", status, check.Name, check.Message)
+			continue
+		}
+		fmt.Printf("%-10s %s: %d / %d (%s)\n", status, check.Name, check.Required, check.Limit, check.LimitSource)
+	}
+
+	return violations
+}
+
+// PreflightGO check destination quotas against source, before any resource created.
+func PreflightGO(awsAccount *AWSAccount, resources []string, update bool) {
+	pf := &preflight{awsAccount: awsAccount}
+
+	if resourceSelected(resources, exportResourceSG) {
+		pf.checkSecurityGroups(update)
+	}
+	if resourceSelected(resources, exportResourceSubnet) {
+		pf.checkSubnets()
+	}
+	if resourceSelected(resources, exportResourceRoute53) {
+		pf.checkRecords()
+	}
+
+	if violations := pf.report(); violations > 0 {
+		exitErrorf("Preflight Failed, %d Violations.", violations)
+	}
+
+	log.Print("Preflight Passed.")
+}
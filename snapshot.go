@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// snapshotSchemaVersion 0 is the legacy array of ec2.SecurityGroup.
+const snapshotSchemaVersion = 1
+
+// Snapshot security groups export document, independent of sdk struct.
+type Snapshot struct {
+	SchemaVersion  int                     `json:"SchemaVersion"`
+	Metadata       SnapshotMetadata        `json:"Metadata"`
+	SecurityGroups []SnapshotSecurityGroup `json:"SecurityGroups"`
+	PrefixLists    []SnapshotPrefixList    `json:"PrefixLists"`
+}
+
+// SnapshotMetadata ...
+type SnapshotMetadata struct {
+	AccountID   string    `json:"AccountID"`
+	Region      string    `json:"Region"`
+	VPCID       string    `json:"VPCID"`
+	CreatedAt   time.Time `json:"CreatedAt"`
+	ToolVersion string    `json:"ToolVersion"`
+}
+
+// SnapshotSecurityGroup ...
+type SnapshotSecurityGroup struct {
+	GroupID     string   `json:"GroupID"`
+	GroupName   string   `json:"GroupName"`
+	Description string   `json:"Description"`
+	VpcID       string   `json:"VpcID"`
+	OwnerID     string   `json:"OwnerID"`
+	Tags        []Tag    `json:"Tags"`
+	Rules       []SGRule `json:"Rules"`
+}
+
+// SnapshotPrefixList referenced prefix list with entries.
+type SnapshotPrefixList struct {
+	PrefixListID  string                    `json:"PrefixListID"`
+	Name          string                    `json:"Name"`
+	AddressFamily string                    `json:"AddressFamily"`
+	MaxEntries    int64                     `json:"MaxEntries"`
+	Entries       []SnapshotPrefixListEntry `json:"Entries"`
+	Tags          []Tag                     `json:"Tags"`
+}
+
+// SnapshotPrefixListEntry ...
+type SnapshotPrefixListEntry struct {
+	Cidr        string `json:"Cidr"`
+	Description string `json:"Description,omitempty"`
+}
+
+func newSnapshot(account *awsAuth, sgList []ec2.SecurityGroup) *Snapshot {
+	snapshot := &Snapshot{
+		SchemaVersion: snapshotSchemaVersion,
+		Metadata: SnapshotMetadata{
+			AccountID:   getAccountID(account),
+			Region:      account.Region,
+			VPCID:       account.VIPCID,
+			CreatedAt:   time.Now().UTC(),
+			ToolVersion: appVersion,
+		},
+	}
+
+	for _, sg := range sgList {
+		snapshot.SecurityGroups = append(snapshot.SecurityGroups, newSnapshotSecurityGroup(sg))
+	}
+
+	snapshot.PrefixLists = getSnapshotPrefixLists(account, snapshot.SecurityGroups)
+
+	return snapshot
+}
+
+func newSnapshotSecurityGroup(sg ec2.SecurityGroup) SnapshotSecurityGroup {
+	tags := []Tag{}
+	for _, t := range sg.Tags {
+		tags = append(tags, Tag{Key: aws.StringValue(t.Key), Value: aws.StringValue(t.Value)})
+	}
+
+	rules := flattenSGRules(sg)
+	if rules == nil {
+		rules = []SGRule{}
+	}
+
+	return SnapshotSecurityGroup{
+		GroupID:     aws.StringValue(sg.GroupId),
+		GroupName:   aws.StringValue(sg.GroupName),
+		Description: aws.StringValue(sg.Description),
+		VpcID:       aws.StringValue(sg.VpcId),
+		OwnerID:     aws.StringValue(sg.OwnerId),
+		Tags:        tags,
+		Rules:       rules,
+	}
+}
+
+// getSnapshotPrefixLists ingress and egress referenced prefix lists.
+func getSnapshotPrefixLists(account *awsAuth, groups []SnapshotSecurityGroup) []SnapshotPrefixList {
+	svc := newSVC(account)
+
+	prefixLists := []SnapshotPrefixList{}
+	found := make(map[string]bool)
+
+	for _, sg := range groups {
+		for _, rule := range sg.Rules {
+			if len(rule.PrefixListID) == 0 || found[rule.PrefixListID] {
+				continue
+			}
+			found[rule.PrefixListID] = true
+
+			var perfixListInfo *ec2.DescribeManagedPrefixListsResponse
+			err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+				perfixListInfo, err = svc.DescribeManagedPrefixListsRequest(&ec2.DescribeManagedPrefixListsInput{
+					PrefixListIds: []string{rule.PrefixListID},
+				}).Send(ctx)
+				return err
+			})
+			if err != nil || len(perfixListInfo.PrefixLists) == 0 {
+				log.Printf("Describe PerfixList %s Error, %v", rule.PrefixListID, err)
+				continue
+			}
+			pl := perfixListInfo.PrefixLists[0]
+
+			var result *ec2.GetManagedPrefixListEntriesResponse
+			err = limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+				result, err = svc.GetManagedPrefixListEntriesRequest(&ec2.GetManagedPrefixListEntriesInput{
+					PrefixListId: aws.String(rule.PrefixListID),
+				}).Send(ctx)
+				return err
+			})
+			if err != nil {
+				log.Printf("Get PerfixList %s Entries Error, %v", rule.PrefixListID, err)
+				continue
+			}
+
+			spl := SnapshotPrefixList{
+				PrefixListID:  rule.PrefixListID,
+				Name:          aws.StringValue(pl.PrefixListName),
+				AddressFamily: aws.StringValue(pl.AddressFamily),
+				MaxEntries:    aws.Int64Value(pl.MaxEntries),
+				Entries:       []SnapshotPrefixListEntry{},
+				Tags:          []Tag{},
+			}
+			for _, e := range result.Entries {
+				spl.Entries = append(spl.Entries, SnapshotPrefixListEntry{
+					Cidr:        aws.StringValue(e.Cidr),
+					Description: aws.StringValue(e.Description),
+				})
+			}
+			for _, t := range pl.Tags {
+				spl.Tags = append(spl.Tags, Tag{Key: aws.StringValue(t.Key), Value: aws.StringValue(t.Value)})
+			}
+
+			prefixLists = append(prefixLists, spl)
+
+			log.Printf("Found PerfixList: %s, Add To Snapshot", rule.PrefixListID)
+		}
+	}
+
+	return prefixLists
+}
+
+// loadSnapshot read snapshot file, legacy array of ec2.SecurityGroup convert to schema version 0.
+func loadSnapshot(filePath string) *Snapshot {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		var ec2SecurityGroups []ec2.SecurityGroup
+		err = json.Unmarshal(content, &ec2SecurityGroups)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		snapshot := &Snapshot{}
+		for _, sg := range ec2SecurityGroups {
+			snapshot.SecurityGroups = append(snapshot.SecurityGroups, newSnapshotSecurityGroup(sg))
+		}
+
+		log.Printf("Loaded Legacy Snapshot: %s, Security Groups: %d", filePath, len(snapshot.SecurityGroups))
+		return snapshot
+	}
+
+	snapshot := &Snapshot{}
+	err = json.Unmarshal(content, snapshot)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if snapshot.SchemaVersion < 1 || snapshot.SchemaVersion > snapshotSchemaVersion {
+		log.Fatalf("Unsupported snapshot schema version: %d, file: %s", snapshot.SchemaVersion, filePath)
+	}
+
+	log.Printf("Loaded Snapshot: %s, Account: %s, Region: %s, Created At: %s, Security Groups: %d",
+		filePath, snapshot.Metadata.AccountID, snapshot.Metadata.Region, snapshot.Metadata.CreatedAt, len(snapshot.SecurityGroups))
+
+	return snapshot
+}
+
+func (snapshot *Snapshot) write(fileName string) {
+	buff, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = ioutil.WriteFile(fileName, buff, 0644)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Output File: %s, Export Done.", fileName)
+}
+
+// ipPermissions convert snapshot rules to ip permissions, one source per permission.
+func (sg *SnapshotSecurityGroup) ipPermissions() (ingress []ec2.IpPermission, egress []ec2.IpPermission) {
+	for _, rule := range sg.Rules {
+		ipp := ec2.IpPermission{
+			IpProtocol: aws.String(rule.Protocol),
+		}
+		if rule.Protocol != "-1" {
+			ipp.FromPort = aws.Int64(rule.FromPort)
+			ipp.ToPort = aws.Int64(rule.ToPort)
+		}
+
+		var description *string
+		if len(rule.Description) > 0 {
+			description = aws.String(rule.Description)
+		}
+
+		switch {
+		case len(rule.CidrIPv4) > 0:
+			ipp.IpRanges = []ec2.IpRange{{CidrIp: aws.String(rule.CidrIPv4), Description: description}}
+		case len(rule.CidrIPv6) > 0:
+			ipp.Ipv6Ranges = []ec2.Ipv6Range{{CidrIpv6: aws.String(rule.CidrIPv6), Description: description}}
+		case len(rule.PrefixListID) > 0:
+			ipp.PrefixListIds = []ec2.PrefixListId{{PrefixListId: aws.String(rule.PrefixListID), Description: description}}
+		case rule.Self:
+			ipp.UserIdGroupPairs = []ec2.UserIdGroupPair{{GroupId: aws.String(sg.GroupID), Description: description}}
+		default:
+			ugp := ec2.UserIdGroupPair{GroupId: aws.String(rule.GroupID), Description: description}
+			if i := strings.Index(rule.GroupID, "/"); i > 0 {
+				ugp.UserId = aws.String(rule.GroupID[:i])
+				ugp.GroupId = aws.String(rule.GroupID[i+1:])
+			}
+			ipp.UserIdGroupPairs = []ec2.UserIdGroupPair{ugp}
+		}
+
+		if rule.Type == "egress" {
+			egress = append(egress, ipp)
+		} else {
+			ingress = append(ingress, ipp)
+		}
+	}
+
+	return ingress, egress
+}
+
+// mapByName match security groups by name within account VPC, create missing groups and prefix lists,
+// rewrite group and prefix list references to the account.
+func (snapshot *Snapshot) mapByName(account *awsAuth) {
+	svc := newSVC(account)
+
+	liveSGNameMap := make(map[string]ec2.SecurityGroup)
+	for _, sg := range GetSGList(account) {
+		if aws.StringValue(sg.VpcId) == account.VIPCID {
+			liveSGNameMap[aws.StringValue(sg.GroupName)] = sg
+		}
+	}
+
+	// snapshot id: account id
+	idMap := make(map[string]string)
+
+	for _, sg := range snapshot.SecurityGroups {
+		if liveSG, ok := liveSGNameMap[sg.GroupName]; ok {
+			idMap[sg.GroupID] = aws.StringValue(liveSG.GroupId)
+			log.Printf("Mapped Security Group %s: %s -> %s", sg.GroupName, sg.GroupID, idMap[sg.GroupID])
+			continue
+		}
+
+		var result *ec2.CreateSecurityGroupResponse
+		err := awsCall(serviceEC2, "Create Security Group", sg.GroupName+" With VPC "+account.VIPCID, nil, func(ctx context.Context) (err error) {
+			result, err = svc.CreateSecurityGroupRequest(&ec2.CreateSecurityGroupInput{
+				DryRun:            aws.Bool(dryRun),
+				Description:       aws.String(sg.Description),
+				GroupName:         aws.String(sg.GroupName),
+				VpcId:             aws.String(account.VIPCID),
+				TagSpecifications: snapshotTagSpecifications(sg.Tags, ec2.ResourceTypeSecurityGroup),
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			if dryRunOK(err, "Create Security Group %s With VPC %s", sg.GroupName, account.VIPCID) {
+				idMap[sg.GroupID] = aws.StringValue(dryRunID(sg.GroupName))
+				continue
+			}
+			// created by a timed out attempt, name unique within VPC.
+			if errorCode(err) == errCodeGroupDuplicate {
+				idMap[sg.GroupID] = aws.StringValue(getSGIDByName(account, account.VIPCID, sg.GroupName))
+				log.Printf("Security group %s already exists in VPC %s, reuse %s.", sg.GroupName, account.VIPCID, idMap[sg.GroupID])
+				continue
+			}
+			exitErrorf("%v", err)
+		}
+
+		idMap[sg.GroupID] = aws.StringValue(result.GroupId)
+		log.Printf("Created security group %s(%s) with VPC %s.", sg.GroupName, idMap[sg.GroupID], account.VIPCID)
+	}
+
+	for _, pl := range snapshot.PrefixLists {
+		if id := findPrefixListByName(svc, snapshotPrefixListName(pl.Name, account.Region)); len(id) > 0 {
+			idMap[pl.PrefixListID] = id
+			log.Printf("Mapped PerfixList %s: %s -> %s", pl.Name, pl.PrefixListID, id)
+			continue
+		}
+
+		// aws managed prefix list can not be created.
+		if strings.HasPrefix(pl.Name, "com.amazonaws.") {
+			log.Printf("Not Found AWS Managed PerfixList %s In %s, Ignore.", pl.Name, account.Region)
+			continue
+		}
+
+		entries := []ec2.AddPrefixListEntry{}
+		for _, e := range pl.Entries {
+			entry := ec2.AddPrefixListEntry{Cidr: aws.String(e.Cidr)}
+			if len(e.Description) > 0 {
+				entry.Description = aws.String(e.Description)
+			}
+			entries = append(entries, entry)
+		}
+
+		token := clientToken(pl.PrefixListID)
+
+		var result *ec2.CreateManagedPrefixListResponse
+		err := awsCall(serviceEC2, "Create PerfixList", pl.Name, nil, func(ctx context.Context) (err error) {
+			result, err = svc.CreateManagedPrefixListRequest(&ec2.CreateManagedPrefixListInput{
+				DryRun:            aws.Bool(dryRun),
+				ClientToken:       token,
+				AddressFamily:     aws.String(pl.AddressFamily),
+				Entries:           entries,
+				PrefixListName:    aws.String(pl.Name),
+				MaxEntries:        aws.Int64(pl.MaxEntries),
+				TagSpecifications: snapshotTagSpecifications(pl.Tags, "prefix-list"),
+			}).Send(ctx)
+			return err
+		})
+		if err != nil {
+			if dryRunOK(err, "Create PerfixList %s", pl.Name) {
+				idMap[pl.PrefixListID] = aws.StringValue(dryRunID(pl.PrefixListID))
+				continue
+			}
+			exitErrorf("%v", err)
+		}
+
+		idMap[pl.PrefixListID] = aws.StringValue(result.PrefixList.PrefixListId)
+		log.Printf("Created PerfixList %s(%s).", pl.Name, idMap[pl.PrefixListID])
+	}
+
+	for i, sg := range snapshot.SecurityGroups {
+		for ii, rule := range sg.Rules {
+			switch {
+			case len(rule.PrefixListID) > 0:
+				if newID, ok := idMap[rule.PrefixListID]; ok {
+					sg.Rules[ii].PrefixListID = newID
+				} else {
+					log.Printf("Not Found PerfixList %s Mapping, Security Group %s Keep Reference.", rule.PrefixListID, sg.GroupName)
+				}
+			case len(rule.GroupID) > 0 && !strings.Contains(rule.GroupID, "/"):
+				if newID, ok := idMap[rule.GroupID]; ok {
+					sg.Rules[ii].GroupID = newID
+				} else {
+					log.Printf("Not Found Security Group %s Mapping, Security Group %s Keep Reference.", rule.GroupID, sg.GroupName)
+				}
+			}
+		}
+		snapshot.SecurityGroups[i].GroupID = idMap[sg.GroupID]
+	}
+}
+
+// snapshotPrefixListName aws managed prefix list name has region, e.g. com.amazonaws.ap-southeast-1.s3
+func snapshotPrefixListName(name, region string) string {
+	if !strings.HasPrefix(name, "com.amazonaws.") {
+		return name
+	}
+
+	fields := strings.SplitN(name, ".", 4)
+	if len(fields) == 4 {
+		fields[2] = region
+	}
+	return strings.Join(fields, ".")
+}
+
+func findPrefixListByName(svc *ec2.Client, name string) string {
+	var result *ec2.DescribeManagedPrefixListsResponse
+	err := limitedCall(serviceEC2, func(ctx context.Context) (err error) {
+		result, err = svc.DescribeManagedPrefixListsRequest(&ec2.DescribeManagedPrefixListsInput{
+			Filters: []ec2.Filter{
+				{
+					Name:   aws.String("prefix-list-name"),
+					Values: []string{name},
+				},
+			},
+		}).Send(ctx)
+		return err
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if len(result.PrefixLists) == 0 {
+		return ""
+	}
+	return aws.StringValue(result.PrefixLists[0].PrefixListId)
+}
+
+func snapshotTagSpecifications(tags []Tag, resourceType ec2.ResourceType) []ec2.TagSpecification {
+	ec2Tags := []ec2.Tag{}
+	for _, t := range tags {
+		// aws reserved tag can not be set.
+		if strings.HasPrefix(t.Key, "aws:") {
+			continue
+		}
+		ec2Tags = append(ec2Tags, ec2.Tag{Key: aws.String(t.Key), Value: aws.String(t.Value)})
+	}
+
+	if len(ec2Tags) == 0 {
+		return nil
+	}
+
+	return []ec2.TagSpecification{
+		{
+			ResourceType: resourceType,
+			Tags:         ec2Tags,
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	snapshot := &Snapshot{
+		SchemaVersion: snapshotSchemaVersion,
+		Metadata: SnapshotMetadata{
+			AccountID:   "111111111111",
+			Region:      "us-east-1",
+			VPCID:       "vpc-1",
+			CreatedAt:   time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			ToolVersion: "test",
+		},
+		SecurityGroups: []SnapshotSecurityGroup{
+			{
+				GroupID:     "sg-1",
+				GroupName:   "web",
+				Description: "web",
+				VpcID:       "vpc-1",
+				OwnerID:     "111111111111",
+				Tags:        []Tag{{Key: "Name", Value: "web"}},
+				Rules: []SGRule{
+					{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "10.0.0.0/16", Description: "https"},
+					{Type: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, PrefixListID: "pl-1"},
+					{Type: "egress", Protocol: "-1", GroupID: "222222222222/sg-9"},
+				},
+			},
+		},
+		PrefixLists: []SnapshotPrefixList{
+			{PrefixListID: "pl-1", Name: "office", AddressFamily: "IPv4", MaxEntries: 5,
+				Entries: []SnapshotPrefixListEntry{{Cidr: "192.168.0.0/24", Description: "office"}}, Tags: []Tag{}},
+		},
+	}
+
+	filePath := filepath.Join(t.TempDir(), "snapshot.json")
+	snapshot.write(filePath)
+
+	if got := loadSnapshot(filePath); !reflect.DeepEqual(got, snapshot) {
+		t.Errorf("loadSnapshot = %+v, want %+v", got, snapshot)
+	}
+}
+
+func TestLoadLegacySnapshot(t *testing.T) {
+	legacy := `[
+  {
+    "Description": "web",
+    "GroupId": "sg-1",
+    "GroupName": "web",
+    "IpPermissions": [
+      {
+        "FromPort": 443,
+        "IpProtocol": "tcp",
+        "IpRanges": [{"CidrIp": "10.0.0.0/16", "Description": "https"}],
+        "ToPort": 443,
+        "UserIdGroupPairs": [{"GroupId": "sg-2"}]
+      }
+    ],
+    "IpPermissionsEgress": [
+      {"IpProtocol": "-1", "IpRanges": [{"CidrIp": "0.0.0.0/0"}]}
+    ],
+    "OwnerId": "111111111111",
+    "Tags": [{"Key": "Name", "Value": "web"}],
+    "VpcId": "vpc-1"
+  }
+]`
+
+	filePath := filepath.Join(t.TempDir(), "legacy.json")
+	if err := ioutil.WriteFile(filePath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := loadSnapshot(filePath)
+
+	if snapshot.SchemaVersion != 0 {
+		t.Errorf("schema version = %d, want 0", snapshot.SchemaVersion)
+	}
+	if len(snapshot.SecurityGroups) != 1 {
+		t.Fatalf("security groups = %d, want 1", len(snapshot.SecurityGroups))
+	}
+
+	sg := snapshot.SecurityGroups[0]
+	if sg.GroupID != "sg-1" || sg.GroupName != "web" || sg.VpcID != "vpc-1" || sg.OwnerID != "111111111111" {
+		t.Errorf("security group = %+v", sg)
+	}
+	if !reflect.DeepEqual(sg.Tags, []Tag{{Key: "Name", Value: "web"}}) {
+		t.Errorf("tags = %v", sg.Tags)
+	}
+
+	want := []SGRule{
+		{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "10.0.0.0/16", Description: "https"},
+		{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, GroupID: "sg-2"},
+		{Type: "egress", Protocol: "-1", CidrIPv4: "0.0.0.0/0"},
+	}
+	if !reflect.DeepEqual(sg.Rules, want) {
+		t.Errorf("rules = %+v, want %+v", sg.Rules, want)
+	}
+}
+
+func TestSnapshotIPPermissions(t *testing.T) {
+	sg := SnapshotSecurityGroup{
+		GroupID: "sg-1",
+		Rules: []SGRule{
+			{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "10.0.0.0/16", Description: "https"},
+			{Type: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, PrefixListID: "pl-1"},
+			{Type: "ingress", Protocol: "-1", Self: true},
+			{Type: "egress", Protocol: "tcp", FromPort: 5432, ToPort: 5432, GroupID: "222222222222/sg-9"},
+			{Type: "egress", Protocol: "-1", CidrIPv6: "::/0"},
+		},
+	}
+
+	ingress, egress := sg.ipPermissions()
+
+	wantIngress := []ec2.IpPermission{
+		{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(443), ToPort: aws.Int64(443),
+			IpRanges: []ec2.IpRange{{CidrIp: aws.String("10.0.0.0/16"), Description: aws.String("https")}}},
+		{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(22), ToPort: aws.Int64(22),
+			PrefixListIds: []ec2.PrefixListId{{PrefixListId: aws.String("pl-1")}}},
+		{IpProtocol: aws.String("-1"), UserIdGroupPairs: []ec2.UserIdGroupPair{{GroupId: aws.String("sg-1")}}},
+	}
+	wantEgress := []ec2.IpPermission{
+		{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(5432), ToPort: aws.Int64(5432),
+			UserIdGroupPairs: []ec2.UserIdGroupPair{{UserId: aws.String("222222222222"), GroupId: aws.String("sg-9")}}},
+		{IpProtocol: aws.String("-1"), Ipv6Ranges: []ec2.Ipv6Range{{CidrIpv6: aws.String("::/0")}}},
+	}
+
+	if !reflect.DeepEqual(ingress, wantIngress) {
+		t.Errorf("ingress = %+v, want %+v", ingress, wantIngress)
+	}
+	if !reflect.DeepEqual(egress, wantEgress) {
+		t.Errorf("egress = %+v, want %+v", egress, wantEgress)
+	}
+
+	// rules of restored permissions same as snapshot, self reference is a group pair of the group.
+	restored := newSnapshotSecurityGroup(ec2.SecurityGroup{GroupId: aws.String("sg-1"), IpPermissions: ingress, IpPermissionsEgress: egress})
+	if len(restored.Rules) != len(sg.Rules) {
+		t.Errorf("restored rules = %+v, want %d", restored.Rules, len(sg.Rules))
+	}
+}
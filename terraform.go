@@ -0,0 +1,565 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+const (
+	tfVPC              = "aws_vpc"
+	tfSubnet           = "aws_subnet"
+	tfRouteTable       = "aws_route_table"
+	tfRouteTableAssoc  = "aws_route_table_association"
+	tfVPCCIDRAssoc     = "aws_vpc_ipv4_cidr_block_association"
+	tfPrefixList       = "aws_ec2_managed_prefix_list"
+	tfRoute53Zone      = "aws_route53_zone"
+	tfSecurityGroup    = "aws_security_group"
+	tfRoute53Record    = "aws_route53_record"
+	tfTemplatePattern  = "template/*.tmpl"
+	tfMainTemplate     = "main"
+	tfImportBlock      = "block"
+	tfImportScript     = "script"
+	tfStyleInline      = "inline"
+	tfStyleRule        = "rule"
+	tfStyleVPCRule     = "vpc-rule"
+	tfSGRule           = "aws_security_group_rule"
+	tfVPCSGIngressRule = "aws_vpc_security_group_ingress_rule"
+	tfVPCSGEgressRule  = "aws_vpc_security_group_egress_rule"
+)
+
+var tfInvalidNameChar = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+//go:embed template/*.tmpl
+var tfTemplateFS embed.FS
+
+// TerraformOption ...
+type TerraformOption struct {
+	// import existing resource to state: "", block, script
+	Import string
+	// security group rule style: inline, rule (aws_security_group_rule),
+	// vpc-rule (aws_vpc_security_group_ingress_rule / aws_vpc_security_group_egress_rule)
+	Style string
+	// custom template files or directories, see template/README.md
+	Templates []string
+	// output file extension of custom "main" template, default txt
+	Extension string
+	// rename security groups as destination, source export only
+	Rename *SGRenameConfig
+}
+
+type terraformImport struct {
+	Address string
+	ID      string
+}
+
+// TerraformExport ...
+type TerraformExport struct {
+	tags   *[]Tag
+	option TerraformOption
+
+	// aws id: terraform resource address, e.g. vpc-123: aws_vpc.main
+	refs map[string]string
+	// used resource address
+	names map[string]bool
+	// import id by register order
+	imports []terraformImport
+	// custom "main" template defined, render whole export at once
+	main bool
+}
+
+// TemplateData data of custom "main" template.
+type TemplateData struct {
+	Resources  *ExportResources
+	HostedZone *TerraformHostedZone
+	RecordSets []TerraformRecordSet
+}
+
+// TerraformHostedZone ...
+type TerraformHostedZone struct {
+	*route53.HostedZone
+	ZoneID string
+	VpcID  string
+	Tags   []ec2.Tag
+}
+
+// TerraformSGRule ...
+type TerraformSGRule struct {
+	SGRule
+	SecurityGroupID string
+	LocalName       string
+}
+
+// TerraformRecordSet ...
+type TerraformRecordSet struct {
+	route53.ResourceRecordSet
+	ZoneID    string
+	LocalName string
+}
+
+func newTerraformExport(tags *[]Tag, option TerraformOption) *TerraformExport {
+	switch option.Import {
+	case "", tfImportBlock, tfImportScript:
+	default:
+		log.Fatalf("Unknown terraform import mode: %s", option.Import)
+	}
+
+	switch option.Style {
+	case "":
+		option.Style = tfStyleInline
+	case tfStyleInline, tfStyleRule, tfStyleVPCRule:
+	default:
+		log.Fatalf("Unknown terraform style: %s", option.Style)
+	}
+
+	return &TerraformExport{
+		tags:   tags,
+		option: option,
+		refs:   make(map[string]string),
+		names:  make(map[string]bool),
+	}
+}
+
+// sanitizeTfName convert to valid terraform identifier.
+func sanitizeTfName(name string) string {
+	name = tfInvalidNameChar.ReplaceAllString(name, "_")
+	if len(name) == 0 {
+		return "_"
+	}
+	if c := name[0]; c >= '0' && c <= '9' || c == '-' {
+		name = "_" + name
+	}
+	return name
+}
+
+// register the aws id (also the import id), return unique local name of resource type.
+func (tf *TerraformExport) register(resourceType string, id string, name string) string {
+	if address, ok := tf.refs[id]; ok {
+		return strings.TrimPrefix(address, resourceType+".")
+	}
+
+	if len(name) == 0 {
+		name = id
+	}
+
+	localName := tf.uniqueName(resourceType, name)
+	tf.refs[id] = resourceType + "." + localName
+	tf.imports = append(tf.imports, terraformImport{Address: resourceType + "." + localName, ID: id})
+
+	return localName
+}
+
+// uniqueName reserve sanitized local name of resource type, without import.
+func (tf *TerraformExport) uniqueName(resourceType string, name string) string {
+	name = sanitizeTfName(name)
+
+	localName := name
+	for i := 2; tf.names[resourceType+"."+localName]; i++ {
+		localName = fmt.Sprintf("%s_%d", name, i)
+	}
+	tf.names[resourceType+"."+localName] = true
+
+	return localName
+}
+
+// name return local name of registered aws id.
+func (tf *TerraformExport) name(id interface{}) string {
+	address, ok := tf.refs[derefString(id)]
+	if !ok {
+		log.Fatalf("terraform resource not registered: %s", derefString(id))
+	}
+	return address[strings.Index(address, ".")+1:]
+}
+
+// ref return terraform reference if aws id in export, otherwise quoted id.
+func (tf *TerraformExport) ref(id interface{}) string {
+	if address, ok := tf.refs[derefString(id)]; ok {
+		return address + ".id"
+	}
+	return hclQuote(id)
+}
+
+func derefString(v interface{}) string {
+	switch s := v.(type) {
+	case *string:
+		return aws.StringValue(s)
+	case string:
+		return s
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// hclQuote quote and escape string for hcl.
+func hclQuote(v interface{}) string {
+	s := derefString(v)
+	s = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	).Replace(s)
+	return `"` + s + `"`
+}
+
+// recordValue route53 txt value has quoted, terraform will quote it.
+func recordValue(rrType route53.RRType, v interface{}) string {
+	s := derefString(v)
+	if rrType == route53.RRTypeTxt || rrType == route53.RRTypeSpf {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, `"`), `"`)
+		s = strings.Replace(s, `" "`, `""`, -1)
+	}
+	return s
+}
+
+func (tf *TerraformExport) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"now": time.Now,
+		"customTags": func() *[]Tag {
+			return tf.tags
+		},
+		"name":        tf.name,
+		"ref":         tf.ref,
+		"quote":       hclQuote,
+		"tags":        func(tags []ec2.Tag) []Tag { return mergeTags(tags, tf.tags) },
+		"dnsName":     func(v interface{}) string { return strings.Replace(derefString(v), `\052`, "*", -1) },
+		"recordValue": recordValue,
+		"str":         derefString,
+		"assocID":     routeTableAssocID,
+		"bool":        aws.BoolValue,
+		"sgRules":     flattenSGRules,
+		"inlineRules": func() bool { return tf.option.Style == tfStyleInline },
+		"sanitize":    sanitizeTfName,
+		"tagName":     tagName,
+		"join":        strings.Join,
+		"replace":     strings.ReplaceAll,
+		"lower":       strings.ToLower,
+		"upper":       strings.ToUpper,
+	}
+}
+
+// parse the embedded templates, then custom templates, same file name or define name override.
+func (tf *TerraformExport) parse() *template.Template {
+	tmpl, err := template.New("terraform").Funcs(tf.funcMap()).ParseFS(tfTemplateFS, tfTemplatePattern)
+	if err != nil {
+		log.Fatalf("parsing: %s", err)
+	}
+
+	for _, path := range tf.option.Templates {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Fatalf("Unable to load template %q, %v", path, err)
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			files, _ = filepath.Glob(filepath.Join(path, "*.tmpl"))
+			if len(files) == 0 {
+				log.Printf("Not Found Template In %s, Ignore.", path)
+				continue
+			}
+		}
+
+		tmpl, err = tmpl.ParseFiles(files...)
+		if err != nil {
+			log.Fatalf("parsing: %s", err)
+		}
+		log.Printf("Loaded Template: %s", strings.Join(files, ", "))
+	}
+
+	tf.main = tmpl.Lookup(tfMainTemplate) != nil
+
+	return tmpl
+}
+
+// extension of output file, after parse.
+func (tf *TerraformExport) extension() string {
+	if !tf.main {
+		return ".tf"
+	}
+	if len(tf.option.Extension) == 0 {
+		return ".txt"
+	}
+	return "." + strings.TrimPrefix(tf.option.Extension, ".")
+}
+
+func (tf *TerraformExport) execute(tmpl *template.Template, buf *bytes.Buffer, name string, data interface{}) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	err := tmpl.ExecuteTemplate(buf, name, data)
+	if err != nil {
+		log.Fatalf("execution: %s", err)
+	}
+	buf.WriteString("\n")
+}
+
+// sgRuleImportID SGID_TYPE_PROTOCOL_FROM_TO_SOURCE, import id of aws_security_group_rule.
+func sgRuleImportID(sgID string, rule SGRule) string {
+	source := rule.CidrIPv4
+	switch {
+	case rule.Self:
+		source = "self"
+	case len(rule.CidrIPv6) > 0:
+		source = rule.CidrIPv6
+	case len(rule.PrefixListID) > 0:
+		source = rule.PrefixListID
+	case len(rule.GroupID) > 0:
+		source = rule.GroupID
+	}
+
+	protocol := rule.Protocol
+	if protocol == "-1" {
+		protocol = "all"
+	}
+	return fmt.Sprintf("%s_%s_%s_%d_%d_%s", sgID, rule.Type, protocol, rule.FromPort, rule.ToPort, source)
+}
+
+// executeSGRules render standalone rule resources, rules without security group reference first,
+// then rules reference other security groups, same order as addNewUGPMap.
+func (tf *TerraformExport) executeSGRules(tmpl *template.Template, buf *bytes.Buffer, sgList []ec2.SecurityGroup) {
+	if tf.option.Style == tfStyleInline {
+		return
+	}
+
+	var cidrRules, ugpRules []TerraformSGRule
+
+	for _, sg := range sgList {
+		for _, rule := range flattenSGRules(sg) {
+			tfRule := TerraformSGRule{
+				SGRule:          rule,
+				SecurityGroupID: aws.StringValue(sg.GroupId),
+			}
+			name := tf.name(sg.GroupId) + "_" + rule.Type
+
+			switch tf.option.Style {
+			case tfStyleRule:
+				tfRule.LocalName = tf.register(tfSGRule, sgRuleImportID(tfRule.SecurityGroupID, rule), name)
+			case tfStyleVPCRule:
+				resourceType := tfVPCSGIngressRule
+				if rule.Type == "egress" {
+					resourceType = tfVPCSGEgressRule
+				}
+				// import id is security group rule id (sgr-), not found in describe security groups.
+				tfRule.LocalName = tf.uniqueName(resourceType, name)
+			}
+
+			if rule.Self || len(rule.GroupID) > 0 {
+				ugpRules = append(ugpRules, tfRule)
+			} else {
+				cidrRules = append(cidrRules, tfRule)
+			}
+		}
+	}
+
+	if tf.option.Style == tfStyleVPCRule && len(tf.option.Import) > 0 {
+		log.Print("Terraform VPC Security Group Rule Import Unsupported, Only Import Security Groups.")
+	}
+
+	tmplName := "security_group_rule.tmpl"
+	if tf.option.Style == tfStyleVPCRule {
+		tmplName = "vpc_security_group_rule.tmpl"
+	}
+
+	for _, rule := range append(cidrRules, ugpRules...) {
+		tf.execute(tmpl, buf, tmplName, rule)
+	}
+}
+
+// routeTableAssocID SUBNETID/ROUTETABLEID or GATEWAYID/ROUTETABLEID, import id of aws_route_table_association.
+func routeTableAssocID(assoc ec2.RouteTableAssociation) string {
+	target := aws.StringValue(assoc.SubnetId)
+	if len(target) == 0 {
+		target = aws.StringValue(assoc.GatewayId)
+	}
+	return target + "/" + aws.StringValue(assoc.RouteTableId)
+}
+
+// registerVPCAssociations secondary cidr blocks, same condition as vpc.tmpl.
+func (tf *TerraformExport) registerVPCAssociations(vpc ec2.Vpc) {
+	for i, cba := range vpc.CidrBlockAssociationSet {
+		if aws.StringValue(cba.CidrBlock) == aws.StringValue(vpc.CidrBlock) ||
+			cba.CidrBlockState == nil || cba.CidrBlockState.State != ec2.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		tf.register(tfVPCCIDRAssoc, aws.StringValue(cba.AssociationId), fmt.Sprintf("%s_%d", tf.name(vpc.VpcId), i))
+	}
+}
+
+// registerRouteTableAssociations subnet and gateway associations, same condition as route_table.tmpl.
+// aws_main_route_table_association can not be imported, terraform replaces main route table of vpc.
+func (tf *TerraformExport) registerRouteTableAssociations(rt ec2.RouteTable) {
+	for i, assoc := range rt.Associations {
+		switch {
+		case aws.BoolValue(assoc.Main):
+			if len(tf.option.Import) > 0 {
+				log.Printf("Terraform Main Route Table Association Import Unsupported, Route Table: %s.", aws.StringValue(rt.RouteTableId))
+			}
+		case assoc.SubnetId != nil, assoc.GatewayId != nil:
+			tf.register(tfRouteTableAssoc, routeTableAssocID(assoc), fmt.Sprintf("%s_%d", tf.name(rt.RouteTableId), i))
+		}
+	}
+}
+
+// ExportTerraform ...
+func ExportTerraform(account *awsAuth, filePath string, resources []string, tags *[]Tag, option TerraformOption) {
+	tf := newTerraformExport(tags, option)
+	res := getExportResources(account, resources)
+	res.SecurityGroups = tf.renameSGList(res.SecurityGroups)
+
+	var (
+		hostedZone *TerraformHostedZone
+		recordSets []TerraformRecordSet
+	)
+
+	// register all resource first, reference is independent of render order.
+	for _, vpc := range res.VPCs {
+		tf.register(tfVPC, aws.StringValue(vpc.VpcId), tagName(vpc.Tags))
+		tf.registerVPCAssociations(vpc)
+	}
+	for _, subnet := range res.Subnets {
+		tf.register(tfSubnet, aws.StringValue(subnet.SubnetId), tagName(subnet.Tags))
+	}
+	for _, rt := range res.RouteTables {
+		tf.register(tfRouteTable, aws.StringValue(rt.RouteTableId), tagName(rt.Tags))
+		tf.registerRouteTableAssociations(rt)
+	}
+	for _, pl := range res.PrefixLists {
+		tf.register(tfPrefixList, aws.StringValue(pl.OldPerfixListID), aws.StringValue(pl.ManagedPrefixList.PrefixListName))
+	}
+	for _, sg := range res.SecurityGroups {
+		tf.register(tfSecurityGroup, aws.StringValue(sg.GroupId), aws.StringValue(sg.GroupName))
+	}
+
+	if res.HostedZone != nil {
+		zoneID := trimHostedZoneID(aws.StringValue(res.HostedZone.Id))
+		tf.register(tfRoute53Zone, zoneID, strings.TrimSuffix(aws.StringValue(res.HostedZone.Name), "."))
+
+		hostedZone = &TerraformHostedZone{
+			HostedZone: res.HostedZone,
+			ZoneID:     zoneID,
+			VpcID:      account.VIPCID,
+			Tags:       res.HostedZoneTags,
+		}
+
+		for _, rr := range res.RecordSets {
+			localName := strings.Replace(strings.TrimSuffix(strings.Replace(aws.StringValue(rr.Name), `\052`, "wildcard", -1), "."), ".", "_", -1)
+			localName = strings.TrimSuffix(strings.Join([]string{localName, string(rr.Type), aws.StringValue(rr.SetIdentifier)}, "_"), "_")
+
+			// import id: ZONEID_NAME_TYPE_SET-IDENTIFIER
+			importID := strings.Join([]string{zoneID, strings.TrimSuffix(strings.Replace(aws.StringValue(rr.Name), `\052`, "*", -1), "."),
+				string(rr.Type), aws.StringValue(rr.SetIdentifier)}, "_")
+
+			recordSets = append(recordSets, TerraformRecordSet{
+				ResourceRecordSet: rr,
+				ZoneID:            zoneID,
+				LocalName:         tf.register(tfRoute53Record, strings.TrimSuffix(importID, "_"), localName),
+			})
+		}
+	}
+
+	tmpl := tf.parse()
+	buf := &bytes.Buffer{}
+
+	if tf.main {
+		tf.execute(tmpl, buf, tfMainTemplate, TemplateData{
+			Resources:  res,
+			HostedZone: hostedZone,
+			RecordSets: recordSets,
+		})
+		tf.write(buf, filepath.Join(filePath, "Export-"+time.Now().Format("20060102150405")+tf.extension()))
+		return
+	}
+
+	for _, vpc := range res.VPCs {
+		tf.execute(tmpl, buf, "vpc.tmpl", vpc)
+	}
+	for _, subnet := range res.Subnets {
+		tf.execute(tmpl, buf, "subnet.tmpl", subnet)
+	}
+	for _, rt := range res.RouteTables {
+		tf.execute(tmpl, buf, "route_table.tmpl", rt)
+	}
+	for _, pl := range res.PrefixLists {
+		tf.execute(tmpl, buf, "prefix_list.tmpl", pl)
+	}
+	for _, sg := range res.SecurityGroups {
+		tf.execute(tmpl, buf, "security_groups.tmpl", sg)
+	}
+	tf.executeSGRules(tmpl, buf, res.SecurityGroups)
+	if hostedZone != nil {
+		tf.execute(tmpl, buf, "route53_zone.tmpl", hostedZone)
+		for _, rr := range recordSets {
+			tf.execute(tmpl, buf, "route53_record.tmpl", rr)
+		}
+	}
+
+	fileName := "Terraform-" + time.Now().Format("20060102150405") + ".tf"
+	tf.write(buf, filepath.Join(filePath, fileName))
+}
+
+// renameSGList rename as destination, imported resources keep the names.
+func (tf *TerraformExport) renameSGList(sgList []ec2.SecurityGroup) []ec2.SecurityGroup {
+	if tf.option.Rename.empty() {
+		return sgList
+	}
+
+	if len(tf.option.Import) > 0 {
+		log.Print("Terraform Import Keep Security Group Names, Ignore Rename.")
+		return sgList
+	}
+
+	return tf.option.Rename.renameSGList(sgList)
+}
+
+// write terraform file, with import blocks or import script.
+func (tf *TerraformExport) write(buf *bytes.Buffer, fileName string) {
+	if tf.main && len(tf.option.Import) > 0 {
+		log.Print("Custom Main Template Unsupported Terraform Import, Ignore.")
+		tf.option.Import = ""
+	}
+
+	switch tf.option.Import {
+	case tfImportBlock:
+		for _, im := range tf.imports {
+			buf.WriteString(fmt.Sprintf("\nimport {\n    to = %s\n    id = %s\n}\n", im.Address, hclQuote(im.ID)))
+		}
+	case tfImportScript:
+		script := &bytes.Buffer{}
+		script.WriteString("#!/bin/sh\nset -e\n\n")
+		for _, im := range tf.imports {
+			script.WriteString(fmt.Sprintf("terraform import '%s' '%s'\n", im.Address, im.ID))
+		}
+
+		scriptName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "-import.sh"
+		err := ioutil.WriteFile(scriptName, script.Bytes(), 0755)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("Output Import Script: %s", scriptName)
+	}
+
+	err := ioutil.WriteFile(fileName, buf.Bytes(), 0644)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("Output File: %s, Export Done.", fileName)
+}
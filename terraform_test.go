@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+func TestHCLQuote(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"sg-123", `"sg-123"`},
+		{aws.String(`say "hi"`), `"say \"hi\""`},
+		{`C:\tmp`, `"C:\\tmp"`},
+		{"a\nb\tc", `"a\nb\tc"`},
+		{"${var.x} %{if}", `"$${var.x} %%{if}"`},
+		{nil, `""`},
+	}
+
+	for _, tt := range tests {
+		if got := hclQuote(tt.value); got != tt.want {
+			t.Errorf("hclQuote(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRouteTablePropagatingVgws(t *testing.T) {
+	tf := newTerraformExport(nil, TerraformOption{})
+	tf.register(tfVPC, "vpc-1", "main")
+	tf.register(tfRouteTable, "rtb-1", "private")
+
+	rt := ec2.RouteTable{
+		RouteTableId: aws.String("rtb-1"),
+		VpcId:        aws.String("vpc-1"),
+		PropagatingVgws: []ec2.PropagatingVgw{
+			{GatewayId: aws.String("vgw-1")},
+			{GatewayId: aws.String("vgw-2")},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	tf.execute(tf.parse(), buf, "route_table.tmpl", rt)
+
+	out := buf.String()
+	if n := strings.Count(out, "propagating_vgws"); n != 1 {
+		t.Fatalf("propagating_vgws rendered %d times, want 1:\n%s", n, out)
+	}
+	if want := `propagating_vgws = ["vgw-1", "vgw-2"]`; !strings.Contains(out, want) {
+		t.Errorf("want %s in:\n%s", want, out)
+	}
+}
+
+func TestRoute53ZonePrivateZone(t *testing.T) {
+	tests := []struct {
+		private *bool
+		want    bool
+	}{
+		{aws.Bool(true), true},
+		{aws.Bool(false), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		tf := newTerraformExport(nil, TerraformOption{})
+		tf.register(tfRoute53Zone, "Z1", "example.com")
+
+		zone := &TerraformHostedZone{
+			HostedZone: &route53.HostedZone{
+				Name:   aws.String("example.com."),
+				Config: &route53.HostedZoneConfig{PrivateZone: tt.private},
+			},
+			ZoneID: "Z1",
+			VpcID:  "vpc-1",
+		}
+
+		buf := &bytes.Buffer{}
+		tf.execute(tf.parse(), buf, "route53_zone.tmpl", zone)
+
+		if got := strings.Contains(buf.String(), "vpc {"); got != tt.want {
+			t.Errorf("PrivateZone %v, vpc block rendered %v, want %v:\n%s", aws.BoolValue(tt.private), got, tt.want, buf.String())
+		}
+	}
+}
+
+func TestRoute53ZoneTags(t *testing.T) {
+	tf := newTerraformExport(&[]Tag{{Key: "Team", Value: "ops"}}, TerraformOption{})
+	tf.register(tfRoute53Zone, "Z1", "example.com")
+
+	zone := &TerraformHostedZone{
+		HostedZone: &route53.HostedZone{Name: aws.String("example.com.")},
+		ZoneID:     "Z1",
+		Tags:       route53EC2Tags([]route53.Tag{{Key: aws.String("Env"), Value: aws.String("prod")}}),
+	}
+
+	buf := &bytes.Buffer{}
+	tf.execute(tf.parse(), buf, "route53_zone.tmpl", zone)
+
+	for _, want := range []string{`"Env" = "prod"`, `"Team" = "ops"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("zone tags missing %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRegisterAssociations(t *testing.T) {
+	tf := newTerraformExport(nil, TerraformOption{Import: tfImportBlock})
+
+	vpc := ec2.Vpc{
+		VpcId:     aws.String("vpc-1"),
+		CidrBlock: aws.String("10.0.0.0/16"),
+		CidrBlockAssociationSet: []ec2.VpcCidrBlockAssociation{
+			{AssociationId: aws.String("vpc-cidr-assoc-1"), CidrBlock: aws.String("10.0.0.0/16"),
+				CidrBlockState: &ec2.VpcCidrBlockState{State: ec2.VpcCidrBlockStateCodeAssociated}},
+			{AssociationId: aws.String("vpc-cidr-assoc-2"), CidrBlock: aws.String("10.1.0.0/16"),
+				CidrBlockState: &ec2.VpcCidrBlockState{State: ec2.VpcCidrBlockStateCodeAssociated}},
+		},
+	}
+	rt := ec2.RouteTable{
+		RouteTableId: aws.String("rtb-1"),
+		VpcId:        aws.String("vpc-1"),
+		Associations: []ec2.RouteTableAssociation{
+			{Main: aws.Bool(true), RouteTableId: aws.String("rtb-1")},
+			{SubnetId: aws.String("subnet-1"), RouteTableId: aws.String("rtb-1")},
+			{GatewayId: aws.String("igw-1"), RouteTableId: aws.String("rtb-1")},
+		},
+	}
+
+	tf.register(tfVPC, "vpc-1", "main")
+	tf.registerVPCAssociations(vpc)
+	tf.register(tfRouteTable, "rtb-1", "private")
+	tf.registerRouteTableAssociations(rt)
+
+	want := []terraformImport{
+		{Address: "aws_vpc.main", ID: "vpc-1"},
+		{Address: "aws_vpc_ipv4_cidr_block_association.main_1", ID: "vpc-cidr-assoc-2"},
+		{Address: "aws_route_table.private", ID: "rtb-1"},
+		{Address: "aws_route_table_association.private_1", ID: "subnet-1/rtb-1"},
+		{Address: "aws_route_table_association.private_2", ID: "igw-1/rtb-1"},
+	}
+	if len(tf.imports) != len(want) {
+		t.Fatalf("imports = %v, want %v", tf.imports, want)
+	}
+	for i := range want {
+		if tf.imports[i] != want[i] {
+			t.Errorf("imports[%d] = %v, want %v", i, tf.imports[i], want[i])
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	tmpl := tf.parse()
+	tf.execute(tmpl, buf, "vpc.tmpl", vpc)
+	tf.execute(tmpl, buf, "route_table.tmpl", rt)
+	for _, im := range want {
+		resource := `resource "` + strings.Replace(im.Address, ".", `" "`, 1) + `"`
+		if !strings.Contains(buf.String(), resource) {
+			t.Errorf("resource %s not rendered:\n%s", im.Address, buf.String())
+		}
+	}
+}
+
+func TestExecuteSGRulesStyles(t *testing.T) {
+	sg := ec2.SecurityGroup{
+		GroupId:     aws.String("sg-web"),
+		GroupName:   aws.String("web"),
+		Description: aws.String("web"),
+		VpcId:       aws.String("vpc-1"),
+		IpPermissions: []ec2.IpPermission{
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(5432), ToPort: aws.Int64(5432),
+				UserIdGroupPairs: []ec2.UserIdGroupPair{{GroupId: aws.String("sg-db")}}},
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(22), ToPort: aws.Int64(22),
+				PrefixListIds: []ec2.PrefixListId{{PrefixListId: aws.String("pl-1")}}},
+		},
+		IpPermissionsEgress: []ec2.IpPermission{
+			{IpProtocol: aws.String("-1"), IpRanges: []ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}},
+		},
+	}
+
+	tests := []struct {
+		style   string
+		want    []string
+		notWant []string
+		// rendered before the group reference rule.
+		first string
+	}{
+		{tfStyleInline, []string{
+			"security_groups = [aws_security_group.db.id]",
+			"prefix_list_ids = [aws_ec2_managed_prefix_list.office.id]",
+			"egress {",
+		}, []string{"aws_security_group_rule", "aws_vpc_security_group"}, ""},
+		{tfStyleRule, []string{
+			`resource "aws_security_group_rule" "web_ingress"`,
+			"source_security_group_id = aws_security_group.db.id",
+			"prefix_list_ids   = [aws_ec2_managed_prefix_list.office.id]",
+			`cidr_blocks       = ["0.0.0.0/0"]`,
+		}, []string{"ingress {", "egress {"}, "prefix_list_ids"},
+		{tfStyleVPCRule, []string{
+			`resource "aws_vpc_security_group_ingress_rule" "web_ingress"`,
+			`resource "aws_vpc_security_group_egress_rule" "web_egress"`,
+			"referenced_security_group_id = aws_security_group.db.id",
+			"prefix_list_id    = aws_ec2_managed_prefix_list.office.id",
+			`ip_protocol       = "-1"`,
+		}, []string{"ingress {", "egress {"}, "prefix_list_id "},
+	}
+
+	for _, tt := range tests {
+		tf := newTerraformExport(nil, TerraformOption{Style: tt.style})
+		tf.register(tfSecurityGroup, "sg-web", "web")
+		tf.register(tfSecurityGroup, "sg-db", "db")
+		tf.register(tfPrefixList, "pl-1", "office")
+
+		tmpl := tf.parse()
+		buf := &bytes.Buffer{}
+		tf.execute(tmpl, buf, "security_groups.tmpl", sg)
+		tf.executeSGRules(tmpl, buf, []ec2.SecurityGroup{sg})
+		out := buf.String()
+
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: want %s in:\n%s", tt.style, want, out)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(out, notWant) {
+				t.Errorf("%s: unexpected %s in:\n%s", tt.style, notWant, out)
+			}
+		}
+		if len(tt.first) > 0 && strings.Index(out, tt.first) > strings.Index(out, "aws_security_group.db.id") {
+			t.Errorf("%s: group reference rule rendered before %s:\n%s", tt.style, tt.first, out)
+		}
+	}
+
+	// vpc rule of all protocol without ports.
+	tf := newTerraformExport(nil, TerraformOption{Style: tfStyleVPCRule})
+	tf.register(tfSecurityGroup, "sg-web", "web")
+	buf := &bytes.Buffer{}
+	tf.executeSGRules(tf.parse(), buf, []ec2.SecurityGroup{{GroupId: aws.String("sg-web"), IpPermissionsEgress: sg.IpPermissionsEgress}})
+	if strings.Contains(buf.String(), "from_port") {
+		t.Errorf("vpc rule of protocol -1 rendered ports:\n%s", buf.String())
+	}
+}
+
+func TestSGRuleImportID(t *testing.T) {
+	tests := []struct {
+		rule SGRule
+		want string
+	}{
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "10.0.0.0/16"}, "sg-1_ingress_tcp_443_443_10.0.0.0/16"},
+		{SGRule{Type: "egress", Protocol: "-1", CidrIPv6: "::/0"}, "sg-1_egress_all_0_0_::/0"},
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 22, ToPort: 22, PrefixListID: "pl-1"}, "sg-1_ingress_tcp_22_22_pl-1"},
+		{SGRule{Type: "ingress", Protocol: "tcp", FromPort: 5432, ToPort: 5432, GroupID: "sg-2"}, "sg-1_ingress_tcp_5432_5432_sg-2"},
+		{SGRule{Type: "ingress", Protocol: "-1", Self: true}, "sg-1_ingress_all_0_0_self"},
+	}
+
+	for _, tt := range tests {
+		if got := sgRuleImportID("sg-1", tt.rule); got != tt.want {
+			t.Errorf("sgRuleImportID(%+v) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
@@ -6,9 +6,62 @@ import (
 	"log"
 	"os"
 	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"golang.org/x/term"
 )
 
+func newCredentials(account *awsAuth) aws.StaticCredentialsProvider {
+	return aws.StaticCredentialsProvider{
+		Value: aws.Credentials{
+			AccessKeyID:     account.AccessKey,
+			SecretAccessKey: account.SecretKey,
+			Source:          "config file",
+			// SessionToken:    "",
+		},
+	}
+}
+
+func newAWSConfig(account *awsAuth) aws.Config {
+	cfg, err := external.LoadDefaultAWSConfig(
+		external.WithCredentialsProvider{
+			CredentialsProvider: newCredentials(account),
+		},
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config, %v", err)
+		os.Exit(1)
+	}
+
+	cfg.Region = account.Region
+
+	// Credentials retrieve will be called automatically internally to the SDK
+	// service clients created with the cfg value.
+	_, err = cfg.Credentials.Retrieve(rootCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get credentials, %v", err)
+		os.Exit(1)
+	}
+
+	return cfg
+}
+
+// stdinIsTerminal false if stdin is pipe, file or /dev/null, e.g. CI or cron.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
 func askForConfirmation(s string) bool {
+	if assumeYes {
+		log.Printf("%s [y/n]: y (--yes)", s)
+		return true
+	}
+
+	if !stdinIsTerminal() {
+		log.Fatalf("%s Confirmation Required, But Stdin Is Not A Terminal, Use --yes To Confirm In Non-Interactive Mode.", s)
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
@@ -16,7 +69,7 @@ func askForConfirmation(s string) bool {
 
 		response, err := reader.ReadString('\n')
 		if err != nil {
-			log.Fatal(err)
+			log.Fatalf("Unable to read confirmation, %v, use --yes to confirm in non-interactive mode.", err)
 		}
 
 		response = strings.ToLower(strings.TrimSpace(response))